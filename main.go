@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"os"
@@ -10,7 +11,15 @@ import (
 
 	"github.com/bakito/dns-checker/version"
 
+	"github.com/bakito/dns-checker/pkg/controller"
+	"github.com/bakito/dns-checker/pkg/dashboard"
+	"github.com/bakito/dns-checker/pkg/events"
+	"github.com/bakito/dns-checker/pkg/health"
+	"github.com/bakito/dns-checker/pkg/push"
 	"github.com/bakito/dns-checker/pkg/run"
+	"github.com/bakito/dns-checker/pkg/status"
+	"github.com/bakito/dns-checker/pkg/telemetry"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	log "github.com/sirupsen/logrus"
 )
@@ -81,22 +90,71 @@ func init() {
 }
 
 func main() {
-	go serveMetrics()
+	exporters, err := telemetry.EnabledMetricsExporters()
+	if err != nil {
+		panic(err)
+	}
+	go serveMetrics(exporters.Prometheus)
+
+	if exporters.OTLP {
+		shutdown, err := telemetry.StartMetrics(context.Background(), prometheus.DefaultGatherer)
+		if err != nil {
+			panic(err)
+		}
+		defer func() {
+			if err := shutdown(context.Background()); err != nil {
+				log.WithError(err).Error("Error stopping otlp metrics export")
+			}
+		}()
+	}
+
+	pusher, err := push.FromEnv(prometheus.DefaultGatherer, interval)
+	if err != nil {
+		panic(err)
+	}
+	if pusher != nil {
+		pusher.Start(context.Background())
+	}
+
+	tracing, err := telemetry.TracingEnabled()
+	if err != nil {
+		panic(err)
+	}
+	if tracing {
+		shutdown, err := telemetry.StartTracing(context.Background())
+		if err != nil {
+			panic(err)
+		}
+		defer func() {
+			if err := shutdown(context.Background()); err != nil {
+				log.WithError(err).Error("Error stopping otlp traces export")
+			}
+		}()
+	}
 
 	values := findTargets()
-	if len(values) == 0 {
+	if len(values) == 0 && !run.DiscoveryEnabled() && !controller.Enabled() {
 		panic(fmt.Errorf("env var %s is needed", envTarget))
 	}
 
-	err := run.Check(values, interval, timeout, worker)
+	err = run.Check(values, interval, timeout, worker)
 	if err != nil {
 		panic(err)
 	}
 }
 
-func serveMetrics() {
+func serveMetrics(prometheusEnabled bool) {
 	log.WithField("port", metricsPort).Info("Starting metrics")
-	http.Handle("/metrics", promhttp.Handler())
+	if prometheusEnabled {
+		http.Handle("/metrics", promhttp.Handler())
+	}
+	http.Handle("/api/v1/status", status.Handler())
+	http.Handle("/api/v1/history", status.HistoryHandler())
+	http.Handle("/api/v1/check", run.TriggerHandler())
+	http.Handle("/api/v1/events", events.Handler())
+	http.Handle("/dashboard/", dashboard.Handler())
+	http.Handle("/healthz", health.LivenessHandler())
+	http.Handle("/readyz", health.ReadinessHandler())
 	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%s", metricsPort), nil))
 }
 
@@ -3,6 +3,7 @@ package check
 import (
 	"fmt"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -36,16 +37,33 @@ var (
 	currObjectives map[float64]float64
 	currBuckets    []float64
 
-	errorMetric     *prometheus.GaugeVec
-	durationMetric  *prometheus.GaugeVec
-	summaryMetric   *prometheus.SummaryVec
-	histogramMetric *prometheus.HistogramVec
+	errorMetric      *prometheus.GaugeVec
+	durationMetric   *prometheus.GaugeVec
+	summaryMetric    *prometheus.SummaryVec
+	histogramMetric  *prometheus.HistogramVec
+	stateMetric      *prometheus.GaugeVec
+	transitionMetric *prometheus.CounterVec
+	flappingMetric   *prometheus.GaugeVec
+	lastRunMetric    *prometheus.GaugeVec
+	failuresMetric   *prometheus.CounterVec
+	availableMetric  *prometheus.GaugeVec
+	burnRateMetric   *prometheus.GaugeVec
 
-	metricName          = "dns_checker_check"
-	metricErrorName     string
-	metricDurationName  string
-	metricSummaryName   string
-	metricHistogramName string
+	baseLabelNames = []string{"target", "port", "check_name", "version"}
+	labelNames     = baseLabelNames
+
+	metricName           = "dns_checker_check"
+	metricErrorName      string
+	metricDurationName   string
+	metricSummaryName    string
+	metricHistogramName  string
+	metricStateName      string
+	metricTransitionName string
+	metricFlappingName   string
+	metricLastRunName    string
+	metricFailuresName   string
+	metricAvailableName  string
+	metricBurnRateName   string
 )
 
 // Init initialize the metrics vectors
@@ -59,26 +77,82 @@ func Init(timeout time.Duration) {
 	metricDurationName = metricName + "_duration"
 	metricSummaryName = metricName + "_summary"
 	metricHistogramName = metricName + "_histogram"
+	metricStateName = metricName + "_state"
+	metricTransitionName = metricName + "_state_transitions_total"
+	metricFlappingName = metricName + "_flapping"
+	metricLastRunName = metricName + "_last_run_timestamp_seconds"
+	metricFailuresName = metricName + "_failures_total"
+	metricAvailableName = metricName + "_availability"
+	metricBurnRateName = metricName + "_error_budget_burn_rate"
 
-	labels := []string{"target", "port", "check_name", "version"}
+	initLabels()
+	dropVersion = dropVersionLabel()
+	labelNames = slices.Clone(baseLabelNames)
+	if dropVersion {
+		labelNames = slices.DeleteFunc(labelNames, func(n string) bool { return n == "version" })
+	}
+	labelNames = append(labelNames, targetLabelNames...)
+	labels := slices.Clip(labelNames)
 	errorMetric = promauto.NewGaugeVec(prometheus.GaugeOpts{
-		Name: metricErrorName,
-		Help: "Check resulted in an error; 1 = error, 0 = OK",
+		Name:        metricErrorName,
+		Help:        "Check resulted in an error; 1 = error, 0 = OK",
+		ConstLabels: constLabels,
 	}, labels)
 	durationMetric = promauto.NewGaugeVec(prometheus.GaugeOpts{
-		Name: metricDurationName,
-		Help: "The duration of the check in ms",
+		Name:        metricDurationName,
+		Help:        "The duration of the check in ms",
+		ConstLabels: constLabels,
 	}, labels)
 	summaryMetric = promauto.NewSummaryVec(prometheus.SummaryOpts{
-		Name:       metricSummaryName,
-		Help:       "The duration of resolver lookups  in ms and percentiles",
-		Objectives: objectives(),
+		Name:        metricSummaryName,
+		Help:        "The duration of resolver lookups  in ms and percentiles",
+		ConstLabels: constLabels,
+		Objectives:  objectives(),
+	}, labels)
+	histogramOpts := prometheus.HistogramOpts{
+		Name:        metricHistogramName,
+		Help:        "The duration of resolver lookups in ms and buckets",
+		ConstLabels: constLabels,
+		Buckets:     buckets(timeout),
+	}
+	nativeHistogram(&histogramOpts)
+	histogramMetric = promauto.NewHistogramVec(histogramOpts, labels)
+	stateMetric = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name:        metricStateName,
+		Help:        "The state of the check; 1 = up, 0 = down, -1 = unknown",
+		ConstLabels: constLabels,
+	}, labels)
+	transitionMetric = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name:        metricTransitionName,
+		Help:        "The number of state transitions of the check by new state",
+		ConstLabels: constLabels,
+	}, append(labels, "state"))
+	flappingMetric = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name:        metricFlappingName,
+		Help:        "The check is flapping between up and down; 1 = flapping, 0 = stable",
+		ConstLabels: constLabels,
 	}, labels)
-	histogramMetric = promauto.NewHistogramVec(prometheus.HistogramOpts{
-		Name:    metricHistogramName,
-		Help:    "The duration of resolver lookups in ms and buckets",
-		Buckets: buckets(timeout),
+	lastRunMetric = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name:        metricLastRunName,
+		Help:        "The unix timestamp of the last check execution",
+		ConstLabels: constLabels,
 	}, labels)
+	failuresMetric = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name:        metricFailuresName,
+		Help:        "The number of failed checks by reason",
+		ConstLabels: constLabels,
+	}, append(labels, "reason"))
+	availableMetric = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name:        metricAvailableName,
+		Help:        "The ratio of successful checks within the rolling window",
+		ConstLabels: constLabels,
+	}, append(labels, "window"))
+	burnRateMetric = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name:        metricBurnRateName,
+		Help:        "The rate the error budget of the slo target is consumed within the rolling window; 1 = budget is used up exactly at the end of the window",
+		ConstLabels: constLabels,
+	}, append(labels, "window"))
+	initInfo()
 }
 
 // BaseCheck basic check functionality
@@ -111,26 +185,38 @@ func (c *BaseCheck) Report(address Address, result Result) {
 	fields["duration"] = duration
 	fields["worker"] = result.WorkerID
 	fields["target"] = address.Host
-	values := []string{address.Host}
 	if address.Port != nil {
 		fields["port"] = *address.Port
-		values = append(values, fmt.Sprintf("%d", *address.Port))
-	} else {
-		values = append(values, "")
 	}
-	values = append(values, c.name, version.Version)
 
-	l := log.WithFields(fields)
+	l := log.WithFields(fields).WithFields(LogFields(c.name, address))
 	if result.Err != nil {
-		l.Warnf("%s : %v", c.MessageNOK, result.Err)
-		errorMetric.WithLabelValues(values...).Set(1)
+		l = l.WithField("reason", Classify(result))
+		l.Debugf("%s : %v", c.MessageNOK, result.Err)
 	} else {
 		l.Debug(c.MessageOK)
-		errorMetric.WithLabelValues(values...).Set(0)
 	}
-	durationMetric.WithLabelValues(values...).Set(duration)
-	summaryMetric.WithLabelValues(values...).Observe(duration)
-	histogramMetric.WithLabelValues(values...).Observe(duration)
+	for _, s := range sinks {
+		s.Report(c.name, address, result)
+	}
+}
+
+func labelValues(address Address, name string) []string {
+	values := []string{address.Host}
+	if address.Port != nil {
+		values = append(values, fmt.Sprintf("%d", *address.Port))
+	} else {
+		values = append(values, "")
+	}
+	values = append(values, name)
+	if !dropVersion {
+		values = append(values, version.Version)
+	}
+	target := targetLabelsOf(name, address)
+	for _, n := range targetLabelNames {
+		values = append(values, target[n])
+	}
+	return values
 }
 
 func objectives() map[float64]float64 {
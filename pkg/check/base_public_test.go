@@ -1,16 +1,32 @@
 package check_test
 
 import (
+	"os"
+	"slices"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/bakito/dns-checker/pkg/check"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"gotest.tools/assert"
 	is "gotest.tools/assert/cmp"
 )
 
+var initOnce sync.Once
+
+func initMetrics() {
+	initOnce.Do(func() {
+		// target labels leave spare capacity in the label names of the metric vectors
+		_ = os.Setenv("METRICS_TARGET_LABELS", "labels.host=team:web")
+		defer func() { _ = os.Unsetenv("METRICS_TARGET_LABELS") }()
+		check.Init(time.Second)
+	})
+}
+
 func Test_Setup_Report(t *testing.T) {
-	check.Init(time.Second)
+	initMetrics()
 	bc := check.BaseCheck{}
 	bc.Setup("ok", "nok", "metricName")
 
@@ -20,3 +36,84 @@ func Test_Setup_Report(t *testing.T) {
 		Duration: new(1 * time.Second),
 	})
 }
+
+func Test_DeleteStaleChecks_series(t *testing.T) {
+	initMetrics()
+	bc := check.BaseCheck{}
+	bc.Setup("ok", "nok", "stale")
+	bc.Report(check.Address{Host: "stale.host"}, check.Result{
+		Duration: new(1 * time.Second),
+	})
+	check.ReportState(check.Address{Host: "stale.host"}, "stale", check.StateUnknown, check.StateUp)
+	check.ReportAvailability(check.Address{Host: "stale.host"}, "stale", "5m", 1, 0)
+
+	assert.Assert(t, is.Equal(countSeries(t, "stale.host"), 9))
+
+	check.DeleteStaleChecks(time.Hour)
+	assert.Assert(t, is.Equal(countSeries(t, "stale.host"), 9))
+
+	time.Sleep(time.Millisecond)
+	assert.Assert(t, len(check.DeleteStaleChecks(time.Nanosecond)) > 0)
+	assert.Assert(t, is.Equal(countSeries(t, "stale.host"), 0))
+}
+
+func countSeries(t *testing.T, target string) int {
+	families, err := prometheus.DefaultGatherer.Gather()
+	assert.Assert(t, is.Nil(err))
+	var count int
+	for _, f := range families {
+		for _, m := range f.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "target" && l.GetValue() == target {
+					count++
+				}
+			}
+		}
+	}
+	return count
+}
+
+func Test_Init_info(t *testing.T) {
+	initMetrics()
+	check.ReportTargets(3)
+
+	families, err := prometheus.DefaultGatherer.Gather()
+	assert.Assert(t, is.Nil(err))
+	values := map[string]float64{}
+	for _, f := range families {
+		for _, m := range f.GetMetric() {
+			values[f.GetName()] = m.GetGauge().GetValue()
+			if f.GetName() == "dns_checker_build_info" {
+				assert.Assert(t, is.Len(m.GetLabel(), 3))
+			}
+		}
+	}
+	assert.Assert(t, is.Equal(values["dns_checker_build_info"], 1.))
+	assert.Assert(t, is.Equal(values["dns_checker_targets"], 3.))
+	assert.Assert(t, values["dns_checker_start_time_seconds"] > 0)
+}
+
+func Test_Init_labels(t *testing.T) {
+	initMetrics()
+	address := check.Address{Host: "labels.host"}
+	check.ReportState(address, "labels", check.StateUnknown, check.StateUp)
+	defer check.DeleteStaleChecks(0)
+
+	families, err := prometheus.DefaultGatherer.Gather()
+	assert.Assert(t, is.Nil(err))
+	var names []string
+	for _, f := range families {
+		if f.GetName() != "dns_checker_check_state_transitions_total" {
+			continue
+		}
+		for _, m := range f.GetMetric() {
+			if !slices.ContainsFunc(m.GetLabel(), func(l *dto.LabelPair) bool { return l.GetValue() == address.Host }) {
+				continue
+			}
+			for _, l := range m.GetLabel() {
+				names = append(names, l.GetName())
+			}
+		}
+	}
+	assert.Assert(t, is.DeepEqual(names, []string{"check_name", "port", "state", "target", "team", "version"}))
+}
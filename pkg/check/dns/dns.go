@@ -5,6 +5,7 @@ import (
 	"net"
 
 	"github.com/bakito/dns-checker/pkg/check"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const (
@@ -27,6 +28,8 @@ type dnsCheck struct {
 }
 
 func (c *dnsCheck) Run(ctx context.Context, address check.Address) *check.Result {
+	ctx, span := check.StartSpan(ctx, "resolve", attribute.String("host", address.Host))
 	_, err := net.DefaultResolver.LookupHost(ctx, address.Host)
+	check.EndSpan(span, err)
 	return &check.Result{Err: err}
 }
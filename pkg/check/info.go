@@ -0,0 +1,80 @@
+package check
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/bakito/dns-checker/version"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	// envMetricDropVersionLabel drop the version label from the check metrics; the version is still exposed by the build info metric
+	envMetricDropVersionLabel = "METRICS_DROP_VERSION_LABEL"
+
+	metricInfoNamespace = "dns_checker"
+)
+
+var (
+	startTime = time.Now()
+
+	dropVersion bool
+
+	targetsMetric prometheus.Gauge
+)
+
+// initInfo register the build info, start time and targets metrics
+func initInfo() {
+	promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace:   metricInfoNamespace,
+		Name:        "build_info",
+		Help:        "The build information of the checker; always 1",
+		ConstLabels: buildInfoLabels(),
+	}).Set(1)
+	promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace:   metricInfoNamespace,
+		Name:        "start_time_seconds",
+		Help:        "The unix timestamp the checker was started",
+		ConstLabels: constLabels,
+	}).Set(float64(startTime.UnixNano()) / float64(time.Second))
+	targetsMetric = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace:   metricInfoNamespace,
+		Name:        "targets",
+		Help:        "The number of configured and discovered targets",
+		ConstLabels: constLabels,
+	})
+}
+
+func buildInfoLabels() prometheus.Labels {
+	l := prometheus.Labels{
+		"version":   version.Version,
+		"revision":  version.Revision,
+		"goversion": version.GoVersion,
+	}
+	for k, v := range constLabels {
+		l[k] = v
+	}
+	return l
+}
+
+// ReportTargets report the number of configured and discovered targets
+func ReportTargets(count int) {
+	if targetsMetric != nil {
+		targetsMetric.Set(float64(count))
+	}
+}
+
+// WithVersion the version should be added to the check metrics
+func WithVersion() bool {
+	return !dropVersion
+}
+
+func dropVersionLabel() bool {
+	if val, exists := os.LookupEnv(envMetricDropVersionLabel); exists {
+		drop, _ := strconv.ParseBool(val)
+		return drop
+	}
+	return false
+}
@@ -0,0 +1,226 @@
+package check
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// envMetricConstLabels a ',' separated list of '=' separated labels added to all metrics.
+	//  E.g: "cluster=prod,zone=a"
+	envMetricConstLabels = "METRICS_CONST_LABELS"
+
+	// envMetricTargetLabels a ',' separated list of per target labels; the target (host or host:port) and
+	// a ';' separated list of ':' separated labels are separated by '='.
+	//  E.g: "my.host:443=team:web;tier:1,1.1.1.1=team:dns"
+	envMetricTargetLabels = "METRICS_TARGET_LABELS"
+)
+
+var (
+	builtinLabelNames = []string{"target", "port", "check_name", "version", "state", "reason", "window"}
+
+	constLabels      prometheus.Labels
+	targetLabelNames []string
+	targetLabels     map[string]map[string]string
+
+	// discoveryLabelNames the target label names registered by target discoveries
+	discoveryLabelNames []string
+	discoveryLabels     sync.Map
+)
+
+// Label a custom label
+type Label struct {
+	Name  string
+	Value string
+}
+
+// initLabels parse the custom labels; invalid configurations are ignored
+func initLabels() {
+	var err error
+	if constLabels, err = parseConstLabels(os.Getenv(envMetricConstLabels)); err != nil {
+		log.WithField("env", envMetricConstLabels).WithError(err).Warn("could not parse the const labels, ignoring them")
+		constLabels = nil
+	}
+	if targetLabelNames, targetLabels, err = parseTargetLabels(os.Getenv(envMetricTargetLabels)); err != nil {
+		log.WithField("env", envMetricTargetLabels).WithError(err).Warn("could not parse the target labels, ignoring them")
+		targetLabelNames, targetLabels = nil, nil
+	}
+	for _, n := range discoveryLabelNames {
+		if !slices.Contains(targetLabelNames, n) {
+			targetLabelNames = append(targetLabelNames, n)
+		}
+	}
+	sort.Strings(targetLabelNames)
+	for _, n := range targetLabelNames {
+		if _, ok := constLabels[n]; ok {
+			log.WithField("label", n).Warn("target label overlaps a const label, ignoring the const label")
+			delete(constLabels, n)
+		}
+	}
+}
+
+// CustomLabels the const and target labels of the check of the address; target labels that are not defined have an empty value
+func CustomLabels(name string, address Address) []Label {
+	var labels []Label
+	names := make([]string, 0, len(constLabels))
+	for n := range constLabels {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		labels = append(labels, Label{Name: n, Value: constLabels[n]})
+	}
+	values := targetLabelsOf(name, address)
+	for _, n := range targetLabelNames {
+		labels = append(labels, Label{Name: n, Value: values[n]})
+	}
+	return labels
+}
+
+// LogFields the custom labels of the check of the address as log fields; empty labels are omitted
+func LogFields(name string, address Address) log.Fields {
+	fields := log.Fields{}
+	for _, l := range CustomLabels(name, address) {
+		if l.Value != "" {
+			fields[l.Name] = l.Value
+		}
+	}
+	return fields
+}
+
+// RegisterTargetLabels register the names of the target labels set by a target discovery; must be called before Init.
+// Invalid or reserved names are ignored.
+func RegisterTargetLabels(names ...string) {
+	for _, n := range names {
+		if slices.Contains(discoveryLabelNames, n) {
+			continue
+		}
+		if errorMetric != nil {
+			log.WithField("label", n).Warn("metrics are already initialized, ignoring the target label")
+			continue
+		}
+		if err := validateLabelName(n); err != nil {
+			log.WithField("label", n).WithError(err).Warn("ignoring the target label")
+			continue
+		}
+		discoveryLabelNames = append(discoveryLabelNames, n)
+	}
+}
+
+// SetTargetLabels set the labels of a discovered target; only registered label names are used
+func SetTargetLabels(address Address, labels map[string]string) {
+	discoveryLabels.Store(addressKey(address), labels)
+}
+
+// SetCheckLabels set the labels of a single check of a discovered target; they take precedence over the labels of the target
+func SetCheckLabels(name string, address Address, labels map[string]string) {
+	discoveryLabels.Store(checkKey(name, address), labels)
+}
+
+// targetLabelsOf the labels of the check of the target; labels of host:port take precedence over labels of the host,
+// configured labels take precedence over labels set by a discovery
+func targetLabelsOf(name string, address Address) map[string]string {
+	configured := targetLabels[address.Host]
+	if address.Port != nil {
+		if l, ok := targetLabels[addressKey(address)]; ok {
+			configured = l
+		}
+	}
+	discovered, ok := discoveryLabels.Load(addressKey(address))
+	checkDiscovered, checkOk := discoveryLabels.Load(checkKey(name, address))
+	if !ok && !checkOk {
+		return configured
+	}
+	labels := make(map[string]string)
+	if ok {
+		maps.Copy(labels, discovered.(map[string]string))
+	}
+	if checkOk {
+		maps.Copy(labels, checkDiscovered.(map[string]string))
+	}
+	maps.Copy(labels, configured)
+	return labels
+}
+
+func addressKey(address Address) string {
+	if address.Port != nil {
+		return fmt.Sprintf("%s:%d", address.Host, *address.Port)
+	}
+	return address.Host
+}
+
+func checkKey(name string, address Address) string {
+	return name + "|" + addressKey(address)
+}
+
+func parseConstLabels(value string) (prometheus.Labels, error) {
+	labels := prometheus.Labels{}
+	if strings.TrimSpace(value) == "" {
+		return labels, nil
+	}
+	for l := range strings.SplitSeq(value, Separator) {
+		k, v, ok := strings.Cut(l, "=")
+		k = strings.TrimSpace(k)
+		if !ok {
+			return nil, fmt.Errorf("label %q is not a key=value pair", l)
+		}
+		if err := validateLabelName(k); err != nil {
+			return nil, err
+		}
+		labels[k] = strings.TrimSpace(v)
+	}
+	return labels, nil
+}
+
+func parseTargetLabels(value string) ([]string, map[string]map[string]string, error) {
+	var names []string
+	labels := make(map[string]map[string]string)
+	if strings.TrimSpace(value) == "" {
+		return names, labels, nil
+	}
+	for t := range strings.SplitSeq(value, Separator) {
+		target, pairs, ok := strings.Cut(t, "=")
+		target = strings.TrimSpace(target)
+		if !ok || target == "" {
+			return nil, nil, fmt.Errorf("target labels %q are not a target=labels pair", t)
+		}
+		if labels[target] == nil {
+			labels[target] = make(map[string]string)
+		}
+		for p := range strings.SplitSeq(pairs, ";") {
+			k, v, ok := strings.Cut(p, ":")
+			k = strings.TrimSpace(k)
+			if !ok {
+				return nil, nil, fmt.Errorf("label %q of target %q is not a key:value pair", p, target)
+			}
+			if err := validateLabelName(k); err != nil {
+				return nil, nil, err
+			}
+			labels[target][k] = strings.TrimSpace(v)
+			if !slices.Contains(names, k) {
+				names = append(names, k)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names, labels, nil
+}
+
+func validateLabelName(name string) error {
+	if !model.LabelName(name).IsValidLegacy() || strings.HasPrefix(name, "__") {
+		return fmt.Errorf("label name %q is not valid", name)
+	}
+	if slices.Contains(builtinLabelNames, name) {
+		return fmt.Errorf("label name %q is reserved", name)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package check
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func Test_parseConstLabels(t *testing.T) {
+	l, err := parseConstLabels(" cluster = prod ,zone=a")
+	assert.Assert(t, is.Nil(err))
+	assert.Assert(t, is.DeepEqual(l, prometheus.Labels{"cluster": "prod", "zone": "a"}))
+
+	_, err = parseConstLabels("cluster")
+	assert.Assert(t, is.Error(err, `label "cluster" is not a key=value pair`))
+	_, err = parseConstLabels("target=x")
+	assert.Assert(t, is.Error(err, `label name "target" is reserved`))
+	_, err = parseConstLabels("my-label=x")
+	assert.Assert(t, is.Error(err, `label name "my-label" is not valid`))
+}
+
+func Test_parseTargetLabels(t *testing.T) {
+	names, labels, err := parseTargetLabels("my.host:443=team:web;tier:1, 1.1.1.1=team:dns")
+	assert.Assert(t, is.Nil(err))
+	assert.Assert(t, is.DeepEqual(names, []string{"team", "tier"}))
+	assert.Assert(t, is.DeepEqual(labels, map[string]map[string]string{
+		"my.host:443": {"team": "web", "tier": "1"},
+		"1.1.1.1":     {"team": "dns"},
+	}))
+
+	_, _, err = parseTargetLabels("my.host")
+	assert.Assert(t, is.Error(err, `target labels "my.host" are not a target=labels pair`))
+	_, _, err = parseTargetLabels("my.host=team")
+	assert.Assert(t, is.Error(err, `label "team" of target "my.host" is not a key:value pair`))
+	_, _, err = parseTargetLabels("my.host=window:5m")
+	assert.Assert(t, is.Error(err, `label name "window" is reserved`))
+}
+
+func Test_CustomLabels(t *testing.T) {
+	defer func(c prometheus.Labels, n []string, l map[string]map[string]string) {
+		constLabels, targetLabelNames, targetLabels = c, n, l
+	}(constLabels, targetLabelNames, targetLabels)
+
+	constLabels = prometheus.Labels{"zone": "a", "cluster": "prod"}
+	targetLabelNames = []string{"team", "tier"}
+	targetLabels = map[string]map[string]string{
+		"my.host:443": {"team": "web", "tier": "1"},
+		"my.host":     {"team": "dns"},
+	}
+	port := 443
+	other := 53
+
+	assert.Assert(t, is.DeepEqual(CustomLabels("dns", Address{Host: "my.host", Port: &port}), []Label{
+		{"cluster", "prod"}, {"zone", "a"}, {"team", "web"}, {"tier", "1"},
+	}))
+	assert.Assert(t, is.DeepEqual(CustomLabels("dns", Address{Host: "my.host", Port: &other}), []Label{
+		{"cluster", "prod"}, {"zone", "a"}, {"team", "dns"}, {"tier", ""},
+	}))
+	assert.Assert(t, is.DeepEqual(labelValues(Address{Host: "other.host"}, "dns")[4:], []string{"", ""}))
+	assert.Assert(t, is.DeepEqual(LogFields("dns", Address{Host: "my.host"}), log.Fields{
+		"cluster": "prod", "zone": "a", "team": "dns",
+	}))
+}
+
+func Test_labelValues_dropVersion(t *testing.T) {
+	defer func(d bool) { dropVersion = d }(dropVersion)
+	port := 53
+
+	dropVersion = false
+	assert.Assert(t, is.Len(labelValues(Address{Host: "a", Port: &port}, "dns"), 4+len(targetLabelNames)))
+	dropVersion = true
+	assert.Assert(t, is.DeepEqual(labelValues(Address{Host: "a", Port: &port}, "dns")[:3], []string{"a", "53", "dns"}))
+	assert.Assert(t, is.Len(labelValues(Address{Host: "a", Port: &port}, "dns"), 3+len(targetLabelNames)))
+}
+
+func Test_SetTargetLabels(t *testing.T) {
+	defer func(l map[string]map[string]string) { targetLabels = l }(targetLabels)
+	port := 8080
+	address := Address{Host: "srv.host", Port: &port}
+	targetLabels = map[string]map[string]string{"srv.host": {"team": "web"}}
+
+	assert.Assert(t, is.DeepEqual(targetLabelsOf("dns", address), map[string]string{"team": "web"}))
+
+	SetTargetLabels(address, map[string]string{"srv_weight": "5", "team": "dns"})
+	defer discoveryLabels.Delete(addressKey(address))
+	assert.Assert(t, is.DeepEqual(targetLabelsOf("dns", address), map[string]string{"team": "web", "srv_weight": "5"}))
+	assert.Assert(t, is.Len(targetLabelsOf("dns", Address{Host: "srv.host"}), 1))
+
+	SetCheckLabels("dns@app/web", address, map[string]string{"srv_weight": "7"})
+	defer discoveryLabels.Delete(checkKey("dns@app/web", address))
+	assert.Assert(t, is.DeepEqual(targetLabelsOf("dns@app/web", address), map[string]string{"team": "web", "srv_weight": "7"}))
+	assert.Assert(t, is.DeepEqual(targetLabelsOf("dns", address), map[string]string{"team": "web", "srv_weight": "5"}))
+}
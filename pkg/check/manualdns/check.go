@@ -14,11 +14,16 @@ const (
 
 // New create a new dns resolve check
 func New(dnsHost string) check.Check {
+	return NewNamed(Name, dnsHost)
+}
+
+// NewNamed create a new dns resolve check with a custom name
+func NewNamed(name string, dnsHost string) check.Check {
 	c := &dnsCheck{}
 	c.Setup(
 		fmt.Sprintf("Host resolved with dns server %s", dnsHost),
 		fmt.Sprintf("Error resolving host with dns server %s", dnsHost),
-		Name)
+		name)
 	c.dnsHost = dnsHost
 	return c
 }
@@ -10,8 +10,10 @@ import (
 	"errors"
 	"fmt"
 	"log"
-	"net"
 	"strings"
+
+	"github.com/bakito/dns-checker/pkg/check"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 /*
@@ -126,19 +128,21 @@ func (q dnsQuestion) encode() []byte {
 
 func resolve(ctx context.Context, query []byte, dnsServer string) (byte, error) {
 	// Setup a UDP connection
-	var d net.Dialer
-	conn, err := d.DialContext(ctx, "udp", dnsServer)
+	conn, err := check.Dial(ctx, "udp", dnsServer)
 	if err != nil {
-		return 0, fmt.Errorf("failed to connect: %v", err)
+		return 0, fmt.Errorf("failed to connect: %w", err)
 	}
 	defer func() {
 		_ = conn.Close()
 	}()
 
+	_, span := check.StartSpan(ctx, "query", attribute.String("server", dnsServer))
 	_, _ = conn.Write(query)
 
 	encodedAnswer := make([]byte, len(query))
-	if _, err := bufio.NewReader(conn).Read(encodedAnswer); err != nil {
+	_, err = bufio.NewReader(conn).Read(encodedAnswer)
+	check.EndSpan(span, err)
+	if err != nil {
 		return 0, err
 	}
 
@@ -152,16 +156,16 @@ func responseCode(responseCode byte) (string, error) {
 	case 0:
 		return "Domain exists!", nil
 	case 1:
-		return "", errors.New("format error")
+		return "", check.WithReason(check.ReasonFormErr, errors.New("format error"))
 	case 2:
-		return "", errors.New("server failure")
+		return "", check.WithReason(check.ReasonServFail, errors.New("server failure"))
 	case 3:
-		return "", errors.New("non-existent domain")
+		return "", check.WithReason(check.ReasonNXDomain, errors.New("non-existent domain"))
 	case 9:
-		return "", errors.New("server not authoritative for zone")
+		return "", check.WithReason(check.ReasonNotAuth, errors.New("server not authoritative for zone"))
 	case 10:
-		return "", errors.New("name not in zone")
+		return "", check.WithReason(check.ReasonNotZone, errors.New("name not in zone"))
 	default:
-		return "", fmt.Errorf("unmapped response code for '%d'", responseCode)
+		return "", check.WithReason(check.ReasonRCode, fmt.Errorf("unmapped response code for '%d'", responseCode))
 	}
 }
@@ -0,0 +1,68 @@
+package check
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// envMetricNativeHistogram enable native (sparse) buckets for the histogram metric
+	envMetricNativeHistogram = "METRICS_NATIVE_HISTOGRAM"
+	// envMetricNativeHistogramBucketFactor the max growth factor between two native buckets; must be > 1
+	envMetricNativeHistogramBucketFactor = "METRICS_NATIVE_HISTOGRAM_BUCKET_FACTOR"
+	// envMetricNativeHistogramMaxBuckets the max number of native buckets; the resolution is reduced if exceeded
+	envMetricNativeHistogramMaxBuckets = "METRICS_NATIVE_HISTOGRAM_MAX_BUCKETS"
+	// envMetricNativeHistogramMinResetDuration the min duration between resets of the native histogram if max buckets is exceeded
+	envMetricNativeHistogramMinResetDuration = "METRICS_NATIVE_HISTOGRAM_MIN_RESET_DURATION"
+	// envMetricHistogramClassicBuckets keep the classic buckets if native buckets are enabled
+	envMetricHistogramClassicBuckets = "METRICS_HISTOGRAM_CLASSIC_BUCKETS"
+
+	defaultNativeBucketFactor     = 1.1
+	defaultNativeMaxBuckets       = 160
+	defaultNativeMinResetDuration = time.Hour
+)
+
+// nativeHistogram configure the native buckets of the histogram opts; the classic buckets are kept unless disabled
+func nativeHistogram(opts *prometheus.HistogramOpts) {
+	if enabled, _ := strconv.ParseBool(os.Getenv(envMetricNativeHistogram)); !enabled {
+		return
+	}
+
+	opts.NativeHistogramBucketFactor = defaultNativeBucketFactor
+	opts.NativeHistogramMaxBucketNumber = defaultNativeMaxBuckets
+	opts.NativeHistogramMinResetDuration = defaultNativeMinResetDuration
+
+	if value, exists := os.LookupEnv(envMetricNativeHistogramBucketFactor); exists {
+		if f, err := strconv.ParseFloat(value, 64); err == nil && f > 1 {
+			opts.NativeHistogramBucketFactor = f
+		} else {
+			log.WithFields(log.Fields{"env": envMetricNativeHistogramBucketFactor, "value": value, "default": defaultNativeBucketFactor}).
+				Warn("could not parse the native histogram bucket factor, using the default")
+		}
+	}
+	if value, exists := os.LookupEnv(envMetricNativeHistogramMaxBuckets); exists {
+		if m, err := strconv.ParseUint(value, 10, 32); err == nil {
+			opts.NativeHistogramMaxBucketNumber = uint32(m)
+		} else {
+			log.WithFields(log.Fields{"env": envMetricNativeHistogramMaxBuckets, "value": value, "default": defaultNativeMaxBuckets}).
+				Warn("could not parse the native histogram max buckets, using the default")
+		}
+	}
+	if value, exists := os.LookupEnv(envMetricNativeHistogramMinResetDuration); exists {
+		if d, err := time.ParseDuration(value); err == nil {
+			opts.NativeHistogramMinResetDuration = d
+		} else {
+			log.WithFields(log.Fields{"env": envMetricNativeHistogramMinResetDuration, "value": value, "default": defaultNativeMinResetDuration}).
+				Warn("could not parse the native histogram min reset duration, using the default")
+		}
+	}
+	if value, exists := os.LookupEnv(envMetricHistogramClassicBuckets); exists {
+		if classic, err := strconv.ParseBool(value); err == nil && !classic {
+			opts.Buckets = []float64{}
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package check
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func Test_nativeHistogram(t *testing.T) {
+	classic := []float64{0.5, 1}
+
+	opts := prometheus.HistogramOpts{Buckets: classic}
+	nativeHistogram(&opts)
+	assert.Assert(t, is.Equal(opts.NativeHistogramBucketFactor, 0.))
+	assert.Assert(t, is.DeepEqual(opts.Buckets, classic))
+
+	t.Setenv(envMetricNativeHistogram, "true")
+	opts = prometheus.HistogramOpts{Buckets: classic}
+	nativeHistogram(&opts)
+	assert.Assert(t, is.Equal(opts.NativeHistogramBucketFactor, defaultNativeBucketFactor))
+	assert.Assert(t, is.Equal(opts.NativeHistogramMaxBucketNumber, uint32(defaultNativeMaxBuckets)))
+	assert.Assert(t, is.Equal(opts.NativeHistogramMinResetDuration, defaultNativeMinResetDuration))
+	assert.Assert(t, is.DeepEqual(opts.Buckets, classic))
+
+	t.Setenv(envMetricNativeHistogramBucketFactor, "1.05")
+	t.Setenv(envMetricNativeHistogramMaxBuckets, "100")
+	t.Setenv(envMetricNativeHistogramMinResetDuration, "30m")
+	t.Setenv(envMetricHistogramClassicBuckets, "false")
+	opts = prometheus.HistogramOpts{Buckets: classic}
+	nativeHistogram(&opts)
+	assert.Assert(t, is.Equal(opts.NativeHistogramBucketFactor, 1.05))
+	assert.Assert(t, is.Equal(opts.NativeHistogramMaxBucketNumber, uint32(100)))
+	assert.Assert(t, is.Equal(opts.NativeHistogramMinResetDuration, 30*time.Minute))
+	assert.Assert(t, is.Len(opts.Buckets, 0))
+
+	t.Setenv(envMetricNativeHistogramBucketFactor, "1")
+	opts = prometheus.HistogramOpts{}
+	nativeHistogram(&opts)
+	assert.Assert(t, is.Equal(opts.NativeHistogramBucketFactor, defaultNativeBucketFactor))
+}
@@ -3,7 +3,6 @@ package port
 import (
 	"context"
 	"fmt"
-	"net"
 
 	"github.com/bakito/dns-checker/pkg/check"
 )
@@ -31,8 +30,7 @@ func (c *probeCheck) Run(ctx context.Context, address check.Address) *check.Resu
 	if address.Port == nil {
 		return nil
 	}
-	var d net.Dialer
-	conn, err := d.DialContext(ctx, "tcp", fmt.Sprintf("%v:%v", address.Host, *address.Port))
+	conn, err := check.Dial(ctx, "tcp", fmt.Sprintf("%v:%v", address.Host, *address.Port))
 	if conn != nil {
 		_ = conn.Close()
 	}
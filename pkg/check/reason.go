@@ -0,0 +1,131 @@
+package check
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// Reason the classified reason of a check failure
+type Reason string
+
+const (
+	// ReasonNXDomain the domain does not exist
+	ReasonNXDomain Reason = "nxdomain"
+	// ReasonServFail the dns server failed to answer the query
+	ReasonServFail Reason = "servfail"
+	// ReasonFormErr the dns server could not interpret the query
+	ReasonFormErr Reason = "formerr"
+	// ReasonNotAuth the dns server is not authoritative for the zone
+	ReasonNotAuth Reason = "notauth"
+	// ReasonNotZone the name is not in the zone
+	ReasonNotZone Reason = "notzone"
+	// ReasonRCode the dns server responded with another error response code
+	ReasonRCode Reason = "rcode"
+	// ReasonTimeout the check did not complete in time
+	ReasonTimeout Reason = "timeout"
+	// ReasonRefused the connection was refused
+	ReasonRefused Reason = "refused"
+	// ReasonReset the connection was reset by the peer
+	ReasonReset Reason = "reset"
+	// ReasonNoRoute the host or network is unreachable
+	ReasonNoRoute Reason = "no_route"
+	// ReasonTLSError the tls handshake or certificate verification failed
+	ReasonTLSError Reason = "tls_error"
+	// ReasonAssertionFailed the check completed but the response did not match the expectation
+	ReasonAssertionFailed Reason = "assertion_failed"
+	// ReasonCommandFailed the command of a shell check exited with an error
+	ReasonCommandFailed Reason = "command_failed"
+	// ReasonUnknown the error could not be classified
+	ReasonUnknown Reason = "unknown"
+)
+
+// ReasonError an error with an explicit failure reason
+type ReasonError struct {
+	Reason Reason
+	Err    error
+}
+
+// Error the message of the wrapped error
+func (e *ReasonError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap return the wrapped error
+func (e *ReasonError) Unwrap() error {
+	return e.Err
+}
+
+// WithReason wrap the error with an explicit failure reason
+func WithReason(reason Reason, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ReasonError{Reason: reason, Err: err}
+}
+
+// Classify map the error of a check result into a stable failure reason; returns an empty reason for nil errors
+func Classify(result Result) Reason {
+	err := result.Err
+	if err == nil {
+		return ""
+	}
+
+	var reasonErr *ReasonError
+	if errors.As(err, &reasonErr) {
+		return reasonErr.Reason
+	}
+	if result.TimedOut || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return ReasonTimeout
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		switch {
+		case dnsErr.IsNotFound:
+			return ReasonNXDomain
+		case dnsErr.IsTimeout:
+			return ReasonTimeout
+		case strings.Contains(dnsErr.Err, "server misbehaving"):
+			return ReasonServFail
+		}
+	}
+
+	switch {
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return ReasonRefused
+	case errors.Is(err, syscall.ECONNRESET):
+		return ReasonReset
+	case errors.Is(err, syscall.EHOSTUNREACH), errors.Is(err, syscall.ENETUNREACH):
+		return ReasonNoRoute
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ReasonTimeout
+	}
+
+	var (
+		recordErr tls.RecordHeaderError
+		alertErr  tls.AlertError
+		verifyErr *tls.CertificateVerificationError
+		unknownCA x509.UnknownAuthorityError
+		hostErr   x509.HostnameError
+		certErr   x509.CertificateInvalidError
+	)
+	if errors.As(err, &recordErr) || errors.As(err, &alertErr) || errors.As(err, &verifyErr) ||
+		errors.As(err, &unknownCA) || errors.As(err, &hostErr) || errors.As(err, &certErr) {
+		return ReasonTLSError
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return ReasonCommandFailed
+	}
+	return ReasonUnknown
+}
@@ -0,0 +1,50 @@
+package check
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func Test_Classify(t *testing.T) {
+	tests := []struct {
+		name     string
+		result   Result
+		expected Reason
+	}{
+		{"no error", Result{}, ""},
+		{"explicit reason", Result{Err: fmt.Errorf("query: %w", WithReason(ReasonServFail, errors.New("server failure")))}, ReasonServFail},
+		{"timed out", Result{Err: errors.New("killed"), TimedOut: true}, ReasonTimeout},
+		{"deadline", Result{Err: context.DeadlineExceeded}, ReasonTimeout},
+		{"nxdomain", Result{Err: &net.DNSError{Err: "no such host", IsNotFound: true}}, ReasonNXDomain},
+		{"dns timeout", Result{Err: &net.DNSError{Err: "i/o timeout", IsTimeout: true}}, ReasonTimeout},
+		{"dns server misbehaving", Result{Err: &net.DNSError{Err: "server misbehaving"}}, ReasonServFail},
+		{"refused", Result{Err: dialErr(syscall.ECONNREFUSED)}, ReasonRefused},
+		{"reset", Result{Err: dialErr(syscall.ECONNRESET)}, ReasonReset},
+		{"no route", Result{Err: dialErr(syscall.EHOSTUNREACH)}, ReasonNoRoute},
+		{"network unreachable", Result{Err: dialErr(syscall.ENETUNREACH)}, ReasonNoRoute},
+		{"tls", Result{Err: x509.UnknownAuthorityError{}}, ReasonTLSError},
+		{"unknown", Result{Err: errors.New("boom")}, ReasonUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Assert(t, is.Equal(Classify(tt.result), tt.expected))
+		})
+	}
+}
+
+func Test_WithReason_nil(t *testing.T) {
+	assert.Assert(t, is.Nil(WithReason(ReasonUnknown, nil)))
+}
+
+func dialErr(errno syscall.Errno) error {
+	return &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", errno)}
+}
@@ -21,6 +21,7 @@ const (
 var (
 	queryTimePattern = regexp.MustCompile(`.*;; Query time: (\d+) msec.*`)
 	noErrorPattern   = regexp.MustCompile(`.*status: NOERROR.*`)
+	statusPattern    = regexp.MustCompile(`status: ([A-Z]+)`)
 )
 
 // NewDig create a new dig command check
@@ -48,7 +49,7 @@ func (c *digCheck) Run(ctx context.Context, address check.Address) *check.Result
 
 	// fail if 'NOERROR' is not found
 	if !noErrorPattern.Match(out) {
-		res.Err = errors.New(string(out))
+		res.Err = check.WithReason(statusReason(out), errors.New(string(out)))
 		return res
 	}
 
@@ -64,3 +65,26 @@ func (c *digCheck) Run(ctx context.Context, address check.Address) *check.Result
 
 	return res
 }
+
+// statusReason map the status of the dig output to a failure reason
+func statusReason(out []byte) check.Reason {
+	m := statusPattern.FindSubmatch(out)
+	if m == nil {
+		return check.ReasonAssertionFailed
+	}
+	switch string(m[1]) {
+	case "NXDOMAIN":
+		return check.ReasonNXDomain
+	case "SERVFAIL":
+		return check.ReasonServFail
+	case "FORMERR":
+		return check.ReasonFormErr
+	case "REFUSED":
+		return check.ReasonRefused
+	case "NOTAUTH":
+		return check.ReasonNotAuth
+	case "NOTZONE":
+		return check.ReasonNotZone
+	}
+	return check.ReasonRCode
+}
@@ -0,0 +1,55 @@
+package check
+
+import (
+	"context"
+	"time"
+)
+
+// SinkPrometheus the name of the prometheus sink
+const SinkPrometheus = "prometheus"
+
+var sinks = []Sink{PrometheusSink()}
+
+// Sink receives the results of the checks
+type Sink interface {
+	Report(name string, address Address, result Result)
+}
+
+// BackgroundSink a sink with a background process; it is started with the context of the checker
+type BackgroundSink interface {
+	Sink
+	Start(ctx context.Context)
+	// Wait until the background process is stopped after the context is done
+	Wait()
+}
+
+// SetSinks set the sinks the check results are reported to
+func SetSinks(s ...Sink) {
+	sinks = s
+}
+
+// PrometheusSink the sink reporting the results to the prometheus metric vectors
+func PrometheusSink() Sink {
+	return prometheusSink{}
+}
+
+type prometheusSink struct{}
+
+func (prometheusSink) Report(name string, address Address, result Result) {
+	duration := float64(*result.Duration) / float64(time.Millisecond)
+	values := labelValues(address, name)
+
+	if result.Err != nil {
+		errorMetric.WithLabelValues(values...).Set(1)
+		failuresMetric.WithLabelValues(append(values, string(Classify(result)))...).Inc()
+	} else {
+		errorMetric.WithLabelValues(values...).Set(0)
+	}
+	durationMetric.WithLabelValues(values...).Set(duration)
+	summaryMetric.WithLabelValues(values...).Observe(duration)
+	histogramMetric.WithLabelValues(values...).Observe(duration)
+
+	now := time.Now()
+	lastRunMetric.WithLabelValues(values...).Set(float64(now.UnixNano()) / float64(time.Second))
+	lastRuns.touch(values, now)
+}
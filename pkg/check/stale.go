@@ -0,0 +1,132 @@
+package check
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var lastRuns = newLastRunTracker()
+
+// lastRunTracker keeps the time each label set was reported last
+type lastRunTracker struct {
+	mux     sync.Mutex
+	entries map[string]lastRun
+}
+
+type lastRun struct {
+	values []string
+	at     time.Time
+}
+
+func newLastRunTracker() *lastRunTracker {
+	return &lastRunTracker{entries: make(map[string]lastRun)}
+}
+
+func (t *lastRunTracker) touch(values []string, at time.Time) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	t.entries[strings.Join(values, "\xff")] = lastRun{values: values, at: at}
+}
+
+// expire remove and return the label sets not reported since the cutoff
+func (t *lastRunTracker) expire(cutoff time.Time) [][]string {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	var expired [][]string
+	for k, e := range t.entries {
+		if e.at.Before(cutoff) {
+			expired = append(expired, e.values)
+			delete(t.entries, k)
+		}
+	}
+	return expired
+}
+
+// active the host and port of the address still have reported label sets
+func (t *lastRunTracker) active(address Address) bool {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	for _, e := range t.entries {
+		if addressKey(toAddress(e.values)) == addressKey(address) {
+			return true
+		}
+	}
+	return false
+}
+
+// activeCheck the check of the address still has reported label sets, e.g. after its target labels changed
+func (t *lastRunTracker) activeCheck(name string, address Address) bool {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	for _, e := range t.entries {
+		if e.values[2] == name && addressKey(toAddress(e.values)) == addressKey(address) {
+			return true
+		}
+	}
+	return false
+}
+
+// StaleCheck a target check whose series were deleted
+type StaleCheck struct {
+	Name    string
+	Address Address
+}
+
+// DeleteStaleChecks delete the series of all label sets that have not been reported within maxAge and return
+// the target checks without remaining series; the checks of label sets replaced by changed target labels are not returned.
+// The discovered labels of checks and targets without remaining series are deleted as well.
+func DeleteStaleChecks(maxAge time.Duration) []StaleCheck {
+	expired := lastRuns.expire(time.Now().Add(-maxAge))
+	var stale []StaleCheck
+	for _, values := range expired {
+		sc := StaleCheck{Name: values[2], Address: toAddress(values)}
+		if slices.ContainsFunc(stale, func(s StaleCheck) bool { return checkKey(s.Name, s.Address) == checkKey(sc.Name, sc.Address) }) ||
+			lastRuns.activeCheck(sc.Name, sc.Address) {
+			continue
+		}
+		stale = append(stale, sc)
+		discoveryLabels.Delete(checkKey(sc.Name, sc.Address))
+		if !lastRuns.active(sc.Address) {
+			discoveryLabels.Delete(addressKey(sc.Address))
+		}
+	}
+	if errorMetric == nil {
+		// metrics are not initialized
+		return stale
+	}
+	for _, values := range expired {
+		for _, v := range []interface {
+			DeleteLabelValues(...string) bool
+		}{errorMetric, durationMetric, summaryMetric, histogramMetric, stateMetric, flappingMetric, lastRunMetric} {
+			v.DeleteLabelValues(values...)
+		}
+		for _, v := range []interface {
+			DeletePartialMatch(prometheus.Labels) int
+		}{transitionMetric, failuresMetric, availableMetric, burnRateMetric} {
+			v.DeletePartialMatch(labelMap(values))
+		}
+	}
+	return stale
+}
+
+// toAddress the address of the label values
+func toAddress(values []string) Address {
+	address := Address{Host: values[0]}
+	if p, err := strconv.Atoi(values[1]); err == nil {
+		address.Port = &p
+	}
+	return address
+}
+
+func labelMap(values []string) prometheus.Labels {
+	l := prometheus.Labels{}
+	for i, n := range labelNames {
+		l[n] = values[i]
+	}
+	return l
+}
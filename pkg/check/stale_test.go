@@ -0,0 +1,64 @@
+package check
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func Test_lastRunTracker_expire(t *testing.T) {
+	tr := newLastRunTracker()
+	now := time.Now()
+	tr.touch([]string{"a", "", "dns", "v1"}, now.Add(-time.Minute))
+	tr.touch([]string{"b", "", "dns", "v1"}, now)
+
+	assert.Assert(t, is.DeepEqual(tr.expire(now.Add(-time.Second)), [][]string{{"a", "", "dns", "v1"}}))
+	assert.Assert(t, is.Len(tr.expire(now.Add(-time.Second)), 0))
+
+	tr.touch([]string{"b", "", "dns", "v1"}, now.Add(time.Second))
+	assert.Assert(t, is.Len(tr.expire(now), 0))
+	assert.Assert(t, is.Len(tr.entries, 1))
+}
+
+func Test_DeleteStaleChecks(t *testing.T) {
+	defer func(l *lastRunTracker) { lastRuns = l }(lastRuns)
+	lastRuns = newLastRunTracker()
+	port := 53
+	address := Address{Host: "gone.host", Port: &port}
+	SetTargetLabels(address, map[string]string{"team": "dns"})
+	now := time.Now()
+	lastRuns.touch([]string{"gone.host", "53", "dns", "v1"}, now.Add(-time.Hour))
+	lastRuns.touch([]string{"gone.host", "53", "probe-port", "v1"}, now)
+
+	stale := DeleteStaleChecks(time.Minute)
+	assert.Assert(t, is.DeepEqual(stale, []StaleCheck{{Name: "dns", Address: address}}))
+	_, ok := discoveryLabels.Load(addressKey(address))
+	assert.Assert(t, ok, "labels are kept while the target has series")
+
+	lastRuns.touch([]string{"gone.host", "53", "probe-port", "v1"}, now.Add(-time.Hour))
+	assert.Assert(t, is.Len(DeleteStaleChecks(time.Minute), 1))
+	_, ok = discoveryLabels.Load(addressKey(address))
+	assert.Assert(t, !ok)
+}
+
+func Test_DeleteStaleChecks_changedLabels(t *testing.T) {
+	defer func(l *lastRunTracker) { lastRuns = l }(lastRuns)
+	lastRuns = newLastRunTracker()
+	address := Address{Host: "moved.host"}
+	SetCheckLabels("dns", address, map[string]string{"team": "b"})
+	now := time.Now()
+	lastRuns.touch([]string{"moved.host", "", "dns", "v1", "a"}, now.Add(-time.Hour))
+	lastRuns.touch([]string{"moved.host", "", "dns", "v1", "b"}, now)
+
+	assert.Assert(t, is.Len(DeleteStaleChecks(time.Minute), 0), "the check is still running with the changed labels")
+	_, ok := discoveryLabels.Load(checkKey("dns", address))
+	assert.Assert(t, ok)
+
+	lastRuns.touch([]string{"moved.host", "", "dns", "v1", "b"}, now.Add(-time.Hour))
+	lastRuns.touch([]string{"moved.host", "", "dns", "v1", "c"}, now.Add(-time.Hour))
+	assert.Assert(t, is.DeepEqual(DeleteStaleChecks(time.Minute), []StaleCheck{{Name: "dns", Address: address}}))
+	_, ok = discoveryLabels.Load(checkKey("dns", address))
+	assert.Assert(t, !ok)
+}
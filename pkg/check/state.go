@@ -0,0 +1,53 @@
+package check
+
+import "time"
+
+// State the health state of a target check
+type State string
+
+const (
+	// StateUnknown no state has been determined yet
+	StateUnknown State = "unknown"
+	// StateUp the check is healthy
+	StateUp State = "up"
+	// StateDown the check is failing
+	StateDown State = "down"
+)
+
+// value the metric value of the state; 1 = up, 0 = down, -1 = unknown
+func (s State) value() float64 {
+	switch s {
+	case StateUp:
+		return 1
+	case StateDown:
+		return 0
+	default:
+		return -1
+	}
+}
+
+// ReportState report the state of a check and count the transition if the state changed
+func ReportState(address Address, name string, previous State, current State) {
+	values := labelValues(address, name)
+	lastRuns.touch(values, time.Now())
+	stateMetric.WithLabelValues(values...).Set(current.value())
+	if previous != current {
+		transitionMetric.WithLabelValues(append(values, string(current))...).Inc()
+	}
+}
+
+// ReportFlapping report if a check is flapping
+func ReportFlapping(address Address, name string, flapping bool) {
+	var value float64
+	if flapping {
+		value = 1
+	}
+	flappingMetric.WithLabelValues(labelValues(address, name)...).Set(value)
+}
+
+// ReportAvailability report the availability and error budget burn rate of a check within a rolling window
+func ReportAvailability(address Address, name string, window string, availability float64, burnRate float64) {
+	values := append(labelValues(address, name), window)
+	availableMetric.WithLabelValues(values...).Set(availability)
+	burnRateMetric.WithLabelValues(values...).Set(burnRate)
+}
@@ -0,0 +1,65 @@
+package check
+
+import (
+	"context"
+	"net"
+	"sync"
+	"syscall"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/bakito/dns-checker/pkg/check"
+
+// StartSpan start a span of a check phase; the span is a no-op if tracing is not enabled
+func StartSpan(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// EndSpan end the span and record the error if not nil
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Dial connect to the address, recording the resolve and connect phases as spans
+func Dial(ctx context.Context, network string, address string) (net.Conn, error) {
+	_, resolveSpan := StartSpan(ctx, "resolve", attribute.String("address", address))
+	var lock sync.Mutex
+	var resolved bool
+	var connectSpan trace.Span
+
+	d := net.Dialer{
+		// called after the address is resolved, before connecting
+		ControlContext: func(_ context.Context, network string, address string, _ syscall.RawConn) error {
+			lock.Lock()
+			defer lock.Unlock()
+			if !resolved {
+				resolved = true
+				resolveSpan.End()
+			}
+			if connectSpan == nil {
+				_, connectSpan = StartSpan(ctx, "connect",
+					attribute.String("network", network), attribute.String("address", address))
+			}
+			return nil
+		},
+	}
+	conn, err := d.DialContext(ctx, network, address)
+
+	lock.Lock()
+	defer lock.Unlock()
+	if !resolved {
+		EndSpan(resolveSpan, err)
+	}
+	if connectSpan != nil {
+		EndSpan(connectSpan, err)
+	}
+	return conn, err
+}
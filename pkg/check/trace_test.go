@@ -0,0 +1,47 @@
+package check_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/bakito/dns-checker/pkg/check"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func Test_Dial(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Assert(t, is.Nil(err))
+	defer func() { _ = lis.Close() }()
+
+	ctx, parent := check.StartSpan(context.Background(), "check")
+	conn, err := check.Dial(ctx, "tcp", lis.Addr().String())
+	assert.Assert(t, is.Nil(err))
+	_ = conn.Close()
+	parent.End()
+
+	spans := recorder.Ended()
+	assert.Assert(t, is.Len(spans, 3))
+	assert.Assert(t, is.Equal(spans[0].Name(), "resolve"))
+	assert.Assert(t, is.Equal(spans[1].Name(), "connect"))
+	assert.Assert(t, is.Equal(spans[2].Name(), "check"))
+	assert.Assert(t, is.Equal(spans[0].Parent().SpanID(), spans[2].SpanContext().SpanID()))
+	assert.Assert(t, is.Equal(spans[1].Parent().SpanID(), spans[2].SpanContext().SpanID()))
+
+	_ = lis.Close()
+	recorder = tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	_, err = check.Dial(context.Background(), "tcp", lis.Addr().String())
+	assert.Assert(t, err != nil)
+	spans = recorder.Ended()
+	assert.Assert(t, is.Len(spans, 2))
+	assert.Assert(t, is.Equal(spans[1].Name(), "connect"))
+	assert.Assert(t, is.Len(spans[1].Events(), 1))
+}
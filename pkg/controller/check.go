@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bakito/dns-checker/pkg/check"
+)
+
+// resourceCheck runs an enabled check for a dnscheck resource. The results are reported with the name of the resource check,
+// to keep the state, status and metrics separate from other checks of the same target, and the expectations are applied.
+type resourceCheck struct {
+	check.Check
+	base        check.BaseCheck
+	success     bool
+	maxDuration time.Duration
+}
+
+func newResourceCheck(chk check.Check, resource string, expectations *Expectations) (*resourceCheck, error) {
+	c := &resourceCheck{Check: chk, success: true}
+	if expectations != nil {
+		if expectations.Success != nil {
+			c.success = *expectations.Success
+		}
+		if expectations.MaxDuration != "" {
+			d, err := time.ParseDuration(expectations.MaxDuration)
+			if err != nil || d <= 0 {
+				return nil, fmt.Errorf("spec.expectations.maxDuration %q can not be parsed as duration", expectations.MaxDuration)
+			}
+			c.maxDuration = d
+		}
+	}
+	c.base.Setup(
+		fmt.Sprintf("Check %s of dnscheck %s succeeded", chk.Name(), resource),
+		fmt.Sprintf("Check %s of dnscheck %s failed", chk.Name(), resource),
+		resourceCheckName(chk.Name(), resource))
+	return c, nil
+}
+
+// resourceCheckName the name of a check of a resource
+func resourceCheckName(name string, resource string) string {
+	return name + "@" + resource
+}
+
+func (c *resourceCheck) Name() string {
+	return c.base.Name()
+}
+
+func (c *resourceCheck) Report(address check.Address, result check.Result) {
+	c.base.Report(address, result)
+}
+
+func (c *resourceCheck) Run(ctx context.Context, address check.Address) *check.Result {
+	start := time.Now()
+	result := c.Check.Run(ctx, address)
+	if result == nil {
+		return nil
+	}
+	if !c.success {
+		if result.Err == nil {
+			result.Err = check.WithReason(check.ReasonAssertionFailed, errors.New("expected the check to fail"))
+		} else {
+			result.Err = nil
+		}
+		return result
+	}
+	if result.Err == nil && c.maxDuration > 0 {
+		duration := time.Since(start)
+		if result.Duration != nil {
+			duration = *result.Duration
+		}
+		if duration > c.maxDuration {
+			result.Err = check.WithReason(check.ReasonAssertionFailed,
+				fmt.Errorf("duration %v exceeds the expected maximum of %v", duration, c.maxDuration))
+		}
+	}
+	return result
+}
@@ -0,0 +1,338 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bakito/dns-checker/pkg/check"
+	"github.com/bakito/dns-checker/pkg/discovery/kubernetes"
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// envEnabled run the checks of the dnscheck resources
+	envEnabled = "CONTROLLER"
+	// envNamespaces a ',' separated list of namespaces to watch; all namespaces if not set
+	envNamespaces = "CONTROLLER_NAMESPACES"
+	// envLabels a ',' separated list of the spec label names added to the metrics; other labels are ignored
+	envLabels = "CONTROLLER_LABELS"
+)
+
+// LabelNames the target labels set for the targets of the dnscheck resources
+var LabelNames = []string{"dnscheck_namespace", "dnscheck_name"}
+
+// Enabled the controller mode is enabled
+func Enabled() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv(envEnabled))
+	return enabled
+}
+
+// New create a new controller running the dnscheck resources with the enabled checks;
+// the in-cluster config is used unless KUBECONFIG is set
+func New(checks []check.Check, interval time.Duration) (*Controller, error) {
+	restConfig, err := kubernetes.RestConfig()
+	if err != nil {
+		return nil, err
+	}
+	client, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the kubernetes client: %w", err)
+	}
+	namespaces := split(os.Getenv(envNamespaces))
+	if len(namespaces) == 0 {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+	return newController(client, namespaces, split(os.Getenv(envLabels)), checks, interval), nil
+}
+
+func newController(client dynamic.Interface, namespaces []string, labels []string, checks []check.Check,
+	interval time.Duration) *Controller {
+	return &Controller{
+		client:     client,
+		namespaces: namespaces,
+		labels:     labels,
+		checks:     checks,
+		interval:   interval,
+		resources:  make(map[string]*resource),
+	}
+}
+
+func split(value string) []string {
+	var values []string
+	for v := range strings.SplitSeq(value, check.Separator) {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// Controller watches the dnscheck resources, provides their due checks and writes the results to their status
+type Controller struct {
+	client     dynamic.Interface
+	namespaces []string
+	labels     []string
+	checks     []check.Check
+	interval   time.Duration
+	listers    []cache.GenericLister
+	ctx        context.Context
+
+	mux       sync.Mutex
+	resources map[string]*resource
+}
+
+// Job the checks of a dnscheck resource that are due
+type Job struct {
+	Resource string
+	Address  check.Address
+	Checks   []check.Check
+	Interval time.Duration
+}
+
+// resource the scheduling state of a dnscheck resource
+type resource struct {
+	namespace  string
+	name       string
+	generation int64
+	address    check.Address
+	checks     []check.Check
+	labels     map[string]string
+	interval   time.Duration
+	err        error
+
+	next    time.Time
+	pending int
+	results []Result
+}
+
+// Start the informers, wait for the initial sync and register the allowed label names
+func (c *Controller) Start(ctx context.Context) error {
+	c.ctx = ctx
+	var synced []cache.InformerSynced
+	for _, ns := range c.namespaces {
+		factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(c.client, 0, ns, nil)
+		i := factory.ForResource(GroupVersionResource)
+		c.listers = append(c.listers, i.Lister())
+		synced = append(synced, i.Informer().HasSynced)
+		factory.Start(ctx.Done())
+	}
+
+	log.WithField("namespaces", c.namespaces).Info("Starting dnscheck controller")
+	if !cache.WaitForCacheSync(ctx.Done(), synced...) {
+		return errors.New("failed to sync the dnscheck cache")
+	}
+
+	check.RegisterTargetLabels(append(slices.Clone(LabelNames), c.labels...)...)
+	return nil
+}
+
+// Due the checks of the resources that are due; the next run of the returned resources is scheduled after their interval
+func (c *Controller) Due(now time.Time) []Job {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	var jobs []Job
+	seen := make(map[string]bool)
+	for _, dc := range c.list() {
+		key := dc.Namespace + "/" + dc.Name
+		seen[key] = true
+		r, ok := c.resources[key]
+		if !ok || r.generation != dc.Generation {
+			r = c.newResource(dc)
+			c.resources[key] = r
+			if r.err != nil {
+				log.WithFields(log.Fields{"namespace": r.namespace, "name": r.name}).WithError(r.err).Warn("Invalid dnscheck")
+				go c.writeStatus(r.namespace, r.name, DNSCheckStatus{ObservedGeneration: r.generation, Error: r.err.Error()})
+			}
+		}
+		if r.err != nil || now.Before(r.next) {
+			continue
+		}
+		if r.pending > 0 && now.Before(r.next.Add(r.interval)) {
+			// the previous round is still running; it is given up after another interval
+			continue
+		}
+		r.next = now.Add(r.interval)
+		r.pending = len(r.checks)
+		r.results = nil
+		// the labels are set on each round, as they are deleted with stale series
+		r.setLabels()
+		jobs = append(jobs, Job{Resource: key, Address: r.address, Checks: r.checks, Interval: r.interval})
+	}
+	for key := range c.resources {
+		if !seen[key] {
+			delete(c.resources, key)
+		}
+	}
+	slices.SortFunc(jobs, func(a, b Job) int {
+		return strings.Compare(a.Resource, b.Resource)
+	})
+	return jobs
+}
+
+// Record the result of a check of a resource; the status is written when the results of all its checks are recorded
+func (c *Controller) Record(key string, name string, result check.Result, state check.State) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	r, ok := c.resources[key]
+	if !ok || r.pending == 0 {
+		return
+	}
+	res := Result{Check: strings.TrimSuffix(name, "@"+key), OK: result.Err == nil, State: state}
+	if result.Err != nil {
+		res.Reason = check.Classify(result)
+		res.Error = result.Err.Error()
+	}
+	if result.Duration != nil {
+		res.Duration = result.Duration.String()
+	}
+	r.results = append(r.results, res)
+	r.pending--
+	if r.pending == 0 {
+		c.complete(r)
+	}
+}
+
+// Skip record a check of a resource that does not apply to its address, e.g. a port check without port
+func (c *Controller) Skip(key string) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	r, ok := c.resources[key]
+	if !ok || r.pending == 0 {
+		return
+	}
+	r.pending--
+	if r.pending == 0 {
+		c.complete(r)
+	}
+}
+
+// complete write the results of the completed round of the resource to its status
+func (c *Controller) complete(r *resource) {
+	status := DNSCheckStatus{
+		ObservedGeneration: r.generation,
+		OK:                 true,
+		LastCheckTime:      &metav1.Time{Time: time.Now()},
+		Results:            slices.Clone(r.results),
+	}
+	slices.SortFunc(status.Results, func(a, b Result) int {
+		return strings.Compare(a.Check, b.Check)
+	})
+	for _, res := range status.Results {
+		status.OK = status.OK && res.OK
+	}
+	go c.writeStatus(r.namespace, r.name, status)
+}
+
+// newResource the scheduling state of a dnscheck; the error is set if the spec is not valid
+func (c *Controller) newResource(dc *DNSCheck) *resource {
+	key := dc.Namespace + "/" + dc.Name
+	r := &resource{namespace: dc.Namespace, name: dc.Name, generation: dc.Generation, interval: c.interval}
+	spec := dc.Spec
+	if strings.TrimSpace(spec.Target) == "" {
+		r.err = errors.New("spec.target must be defined")
+		return r
+	}
+	r.address = check.Address{Host: strings.TrimSpace(spec.Target), Port: spec.Port}
+	if spec.Interval != "" {
+		d, err := time.ParseDuration(spec.Interval)
+		if err != nil || d <= 0 {
+			r.err = fmt.Errorf("spec.interval %q can not be parsed as duration", spec.Interval)
+			return r
+		}
+		r.interval = d
+	}
+
+	checks := c.checks
+	if len(spec.Checks) > 0 {
+		checks = nil
+		for _, n := range spec.Checks {
+			idx := slices.IndexFunc(c.checks, func(chk check.Check) bool { return chk.Name() == n })
+			if idx < 0 {
+				r.err = fmt.Errorf("check %q is not enabled", n)
+				return r
+			}
+			checks = append(checks, c.checks[idx])
+		}
+	}
+	for _, chk := range checks {
+		rc, err := newResourceCheck(chk, key, spec.Expectations)
+		if err != nil {
+			r.err = err
+			return r
+		}
+		r.checks = append(r.checks, rc)
+	}
+
+	r.labels = map[string]string{"dnscheck_namespace": dc.Namespace, "dnscheck_name": dc.Name}
+	for k, v := range spec.Labels {
+		if slices.Contains(c.labels, k) {
+			r.labels[k] = v
+		}
+	}
+	return r
+}
+
+// setLabels set the labels of the checks of the resource
+func (r *resource) setLabels() {
+	for _, chk := range r.checks {
+		check.SetCheckLabels(chk.Name(), r.address, r.labels)
+	}
+}
+
+// list the dnscheck resources of all namespaces; resources that can not be converted are skipped
+func (c *Controller) list() []*DNSCheck {
+	var checks []*DNSCheck
+	for _, l := range c.listers {
+		objects, err := l.List(labels.Everything())
+		if err != nil {
+			log.WithError(err).Error("Error listing dnschecks")
+			continue
+		}
+		for _, o := range objects {
+			u, ok := o.(*unstructured.Unstructured)
+			if !ok {
+				continue
+			}
+			dc := &DNSCheck{}
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, dc); err != nil {
+				log.WithFields(log.Fields{"namespace": u.GetNamespace(), "name": u.GetName()}).WithError(err).Warn("Invalid dnscheck")
+				continue
+			}
+			checks = append(checks, dc)
+		}
+	}
+	return checks
+}
+
+// writeStatus replace the status of the resource
+func (c *Controller) writeStatus(namespace string, name string, status DNSCheckStatus) {
+	patch, err := json.Marshal([]map[string]any{{"op": "add", "path": "/status", "value": status}})
+	if err != nil {
+		log.WithError(err).Error("Error encoding the dnscheck status")
+		return
+	}
+	_, err = c.client.Resource(GroupVersionResource).Namespace(namespace).
+		Patch(c.ctx, name, types.JSONPatchType, patch, metav1.PatchOptions{}, "status")
+	if err != nil {
+		log.WithFields(log.Fields{"namespace": namespace, "name": name}).WithError(err).Error("Error writing the dnscheck status")
+	}
+}
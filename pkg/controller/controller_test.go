@@ -0,0 +1,200 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bakito/dns-checker/pkg/check"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+)
+
+func Test_Controller(t *testing.T) {
+	client := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{GroupVersionResource: "DNSCheckList"},
+		dnsCheck("web", "app", map[string]any{
+			"target":   "my.host",
+			"port":     int64(443),
+			"checks":   []any{"probe-port"},
+			"interval": "1m",
+			"labels":   map[string]any{"team": "web"},
+		}),
+		dnsCheck("invalid", "app", map[string]any{"target": "other.host", "checks": []any{"nc"}}),
+	)
+	checks := []check.Check{&testCheck{name: "dns"}, &testCheck{name: "probe-port"}}
+	c := newController(client, []string{metav1.NamespaceAll}, []string{"team"}, checks, 30*time.Second)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assert.Assert(t, is.Nil(c.Start(ctx)))
+
+	now := time.Now()
+	jobs := c.Due(now)
+	assert.Assert(t, is.Len(jobs, 1))
+	p := 443
+	assert.Assert(t, is.Equal(jobs[0].Resource, "app/web"))
+	assert.Assert(t, is.DeepEqual(jobs[0].Address, check.Address{Host: "my.host", Port: &p}))
+	assert.Assert(t, is.Equal(jobs[0].Interval, time.Minute))
+	assert.Assert(t, is.Len(jobs[0].Checks, 1))
+	assert.Assert(t, is.Equal(jobs[0].Checks[0].Name(), "probe-port@app/web"))
+
+	assert.Assert(t, is.Len(c.Due(now.Add(time.Second)), 0))
+	// the round is still running
+	assert.Assert(t, is.Len(c.Due(now.Add(time.Minute)), 0))
+
+	status := waitForStatus(t, ctx, c, "invalid")
+	assert.Assert(t, is.Equal(status.Error, `check "nc" is not enabled`))
+	assert.Assert(t, !status.OK)
+
+	d := 12 * time.Millisecond
+	err := check.WithReason(check.ReasonRefused, errors.New("connection refused"))
+	c.Record("app/web", "probe-port@app/web", check.Result{Duration: &d, Err: err}, check.StateDown)
+	// late results of a completed round are ignored
+	c.Record("app/web", "probe-port@app/web", check.Result{Duration: &d}, check.StateUp)
+	status = waitForStatus(t, ctx, c, "web")
+	assert.Assert(t, !status.OK)
+	assert.Assert(t, is.Equal(status.ObservedGeneration, int64(1)))
+	assert.Assert(t, status.LastCheckTime != nil)
+	assert.Assert(t, is.DeepEqual(status.Results, []Result{{
+		Check:    "probe-port",
+		State:    check.StateDown,
+		Reason:   check.ReasonRefused,
+		Error:    "connection refused",
+		Duration: "12ms",
+	}}))
+	assert.Assert(t, is.Len(c.Due(now.Add(time.Minute)), 1))
+
+	assert.Assert(t, is.Nil(client.Resource(GroupVersionResource).Namespace("app").Delete(ctx, "web", metav1.DeleteOptions{})))
+	for range 200 {
+		if len(c.list()) == 1 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Assert(t, is.Len(c.Due(now.Add(time.Hour)), 0))
+	assert.Assert(t, is.Len(c.resources, 1))
+}
+
+func Test_Controller_withoutPort(t *testing.T) {
+	client := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{GroupVersionResource: "DNSCheckList"},
+		dnsCheck("web", "app", map[string]any{"target": "my.host"}),
+	)
+	checks := []check.Check{&testCheck{name: "dns"}, &testCheck{name: "probe-port"}}
+	c := newController(client, []string{metav1.NamespaceAll}, nil, checks, 30*time.Second)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assert.Assert(t, is.Nil(c.Start(ctx)))
+
+	now := time.Now()
+	assert.Assert(t, is.Len(c.Due(now), 1))
+	d := 3 * time.Millisecond
+	c.Record("app/web", "dns@app/web", check.Result{Duration: &d}, check.StateUp)
+	// the port check does not apply to a resource without port
+	c.Skip("app/web")
+
+	status := waitForStatus(t, ctx, c, "web")
+	assert.Assert(t, status.OK)
+	assert.Assert(t, is.DeepEqual(status.Results, []Result{{Check: "dns", OK: true, State: check.StateUp, Duration: "3ms"}}))
+	// the next round is not blocked by the skipped check
+	assert.Assert(t, is.Len(c.Due(now.Add(30*time.Second)), 1))
+}
+
+func Test_newResource(t *testing.T) {
+	c := newController(nil, nil, []string{"team"}, []check.Check{&testCheck{name: "dns"}}, 30*time.Second)
+
+	r := c.newResource(&DNSCheck{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "app", Name: "web"},
+		Spec:       DNSCheckSpec{Target: "my.host", Labels: map[string]string{"team": "web", "window": "5m"}},
+	})
+	assert.Assert(t, is.Nil(r.err))
+	assert.Assert(t, is.Equal(r.interval, 30*time.Second))
+	assert.Assert(t, is.Len(r.checks, 1))
+	assert.Assert(t, is.Equal(r.checks[0].Name(), "dns@app/web"))
+	// only allowed labels are used
+	assert.Assert(t, is.DeepEqual(r.labels, map[string]string{"dnscheck_namespace": "app", "dnscheck_name": "web", "team": "web"}))
+
+	r = c.newResource(&DNSCheck{Spec: DNSCheckSpec{Target: " "}})
+	assert.Assert(t, is.Error(r.err, "spec.target must be defined"))
+
+	r = c.newResource(&DNSCheck{Spec: DNSCheckSpec{Target: "my.host", Interval: "often"}})
+	assert.Assert(t, is.Error(r.err, `spec.interval "often" can not be parsed as duration`))
+
+	r = c.newResource(&DNSCheck{Spec: DNSCheckSpec{Target: "my.host", Expectations: &Expectations{MaxDuration: "-1s"}}})
+	assert.Assert(t, is.Error(r.err, `spec.expectations.maxDuration "-1s" can not be parsed as duration`))
+}
+
+func Test_resourceCheck(t *testing.T) {
+	d := 200 * time.Millisecond
+	tc := &testCheck{name: "dns", duration: &d}
+
+	chk, err := newResourceCheck(tc, "app/web", &Expectations{Success: new(false)})
+	assert.Assert(t, is.Nil(err))
+	result := chk.Run(context.Background(), check.Address{Host: "blocked.host"})
+	assert.Assert(t, is.Equal(check.Classify(*result), check.ReasonAssertionFailed))
+
+	tc.err = errors.New("no such host")
+	result = chk.Run(context.Background(), check.Address{Host: "blocked.host"})
+	assert.Assert(t, is.Nil(result.Err))
+
+	chk, err = newResourceCheck(tc, "app/web", &Expectations{MaxDuration: "100ms"})
+	assert.Assert(t, is.Nil(err))
+	tc.err = nil
+	result = chk.Run(context.Background(), check.Address{Host: "slow.host"})
+	assert.Assert(t, is.Error(result.Err, "duration 200ms exceeds the expected maximum of 100ms"))
+	assert.Assert(t, is.Equal(chk.Name(), "dns@app/web"))
+
+	chk, err = newResourceCheck(tc, "app/web", nil)
+	assert.Assert(t, is.Nil(err))
+	result = chk.Run(context.Background(), check.Address{Host: "slow.host"})
+	assert.Assert(t, is.Nil(result.Err))
+}
+
+func waitForStatus(t *testing.T, ctx context.Context, c *Controller, name string) DNSCheckStatus {
+	t.Helper()
+	for range 200 {
+		u, err := c.client.Resource(GroupVersionResource).Namespace("app").Get(ctx, name, metav1.GetOptions{})
+		assert.Assert(t, is.Nil(err))
+		if _, ok := u.Object["status"]; ok {
+			dc := &DNSCheck{}
+			assert.Assert(t, is.Nil(runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, dc)))
+			return dc.Status
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("status of %q not written", name)
+	return DNSCheckStatus{}
+}
+
+func dnsCheck(name string, namespace string, spec map[string]any) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": GroupVersionResource.GroupVersion().String(),
+		"kind":       "DNSCheck",
+		"metadata":   map[string]any{"name": name, "namespace": namespace, "generation": int64(1)},
+		"spec":       spec,
+	}}
+}
+
+type testCheck struct {
+	name     string
+	err      error
+	duration *time.Duration
+}
+
+func (c *testCheck) Run(_ context.Context, _ check.Address) *check.Result {
+	return &check.Result{Err: c.err, Duration: c.duration}
+}
+
+func (c *testCheck) Report(_ check.Address, _ check.Result) {}
+
+func (c *testCheck) Name() string {
+	return c.name
+}
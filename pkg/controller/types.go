@@ -0,0 +1,58 @@
+package controller
+
+import (
+	"github.com/bakito/dns-checker/pkg/check"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// GroupVersionResource the resource of the dnscheck custom resources
+var GroupVersionResource = schema.GroupVersionResource{Group: "dns-checker.bakito.ch", Version: "v1alpha1", Resource: "dnschecks"}
+
+// DNSCheck a check declared as kubernetes resource
+type DNSCheck struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DNSCheckSpec   `json:"spec"`
+	Status DNSCheckStatus `json:"status,omitempty"`
+}
+
+// DNSCheckSpec the target and checks of a dnscheck
+type DNSCheckSpec struct {
+	Target string `json:"target"`
+	Port   *int   `json:"port,omitempty"`
+	// Checks the names of the enabled checks to run; all enabled checks if empty
+	Checks []string `json:"checks,omitempty"`
+	// Interval the check interval as duration; the checker interval if empty
+	Interval     string            `json:"interval,omitempty"`
+	Expectations *Expectations     `json:"expectations,omitempty"`
+	Labels       map[string]string `json:"labels,omitempty"`
+}
+
+// Expectations the expected results of the checks
+type Expectations struct {
+	// Success the checks are expected to succeed; false to verify a host is not resolvable or reachable
+	Success *bool `json:"success,omitempty"`
+	// MaxDuration the maximum duration of a successful check
+	MaxDuration string `json:"maxDuration,omitempty"`
+}
+
+// DNSCheckStatus the latest results of a dnscheck
+type DNSCheckStatus struct {
+	ObservedGeneration int64        `json:"observedGeneration,omitempty"`
+	OK                 bool         `json:"ok"`
+	Error              string       `json:"error,omitempty"`
+	LastCheckTime      *metav1.Time `json:"lastCheckTime,omitempty"`
+	Results            []Result     `json:"results,omitempty"`
+}
+
+// Result the latest result of a check
+type Result struct {
+	Check    string       `json:"check"`
+	OK       bool         `json:"ok"`
+	State    check.State  `json:"state"`
+	Reason   check.Reason `json:"reason,omitempty"`
+	Error    string       `json:"error,omitempty"`
+	Duration string       `json:"duration"`
+}
@@ -0,0 +1,19 @@
+package dashboard
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func Test_Handler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/dashboard/", nil))
+	assert.Assert(t, is.Equal(rec.Code, http.StatusOK))
+	assert.Assert(t, is.Equal(rec.Header().Get("Content-Type"), "text/html; charset=utf-8"))
+	assert.Assert(t, strings.Contains(rec.Body.String(), "../api/v1/status"))
+}
@@ -0,0 +1,64 @@
+package discovery
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/bakito/dns-checker/pkg/check"
+)
+
+// Discoverer provides targets discovered at runtime
+type Discoverer interface {
+	// Name the name of the discovery
+	Name() string
+	// Start discovering targets until the context is done; returns after the initial targets are discovered
+	Start(ctx context.Context) error
+	// Targets the currently discovered targets
+	Targets() []check.Address
+}
+
+// CheckDiscoverer a discovery that also provides checks, which are run for all targets
+type CheckDiscoverer interface {
+	Discoverer
+	// Checks the currently discovered checks
+	Checks() []check.Check
+}
+
+// Sort sort the addresses by host and port and remove duplicates
+func Sort(addresses []check.Address) []check.Address {
+	slices.SortFunc(addresses, compare)
+	return slices.CompactFunc(addresses, func(a, b check.Address) bool { return compare(a, b) == 0 })
+}
+
+func compare(a, b check.Address) int {
+	return cmp.Or(cmp.Compare(a.Host, b.Host), cmp.Compare(port(a.Port), port(b.Port)))
+}
+
+func port(p *int) int {
+	if p == nil {
+		return -1
+	}
+	return *p
+}
+
+// ParseAddress parse a host or host:port target
+func ParseAddress(target string) (check.Address, error) {
+	host, p, found := strings.Cut(strings.TrimSpace(target), ":")
+	addr := check.Address{Host: host}
+	if host == "" {
+		return addr, fmt.Errorf("target %q has no host", target)
+	}
+	if !found {
+		return addr, nil
+	}
+	port, err := strconv.Atoi(p)
+	if err != nil {
+		return addr, fmt.Errorf("port %q of host %q can not be parsed as int", p, host)
+	}
+	addr.Port = &port
+	return addr, nil
+}
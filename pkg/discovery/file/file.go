@@ -0,0 +1,169 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bakito/dns-checker/pkg/check"
+	"github.com/bakito/dns-checker/pkg/discovery"
+	log "github.com/sirupsen/logrus"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// Name the name of this discovery
+	Name = "file"
+
+	// envFiles a ',' separated list of file patterns of prometheus file_sd files (json or yaml). E.g: "/etc/targets/*.json"
+	envFiles           = "FILE_SD_FILES"
+	envRefreshInterval = "FILE_SD_REFRESH_INTERVAL"
+
+	defaultRefreshInterval = 5 * time.Minute
+)
+
+// group a prometheus file_sd target group
+type group struct {
+	Targets []string          `json:"targets"`
+	Labels  map[string]string `json:"labels,omitempty"`
+}
+
+type target struct {
+	address check.Address
+	labels  map[string]string
+}
+
+// New create a new file discovery configured by env variables
+func New() (discovery.Discoverer, error) {
+	value, exists := os.LookupEnv(envFiles)
+	if !exists || strings.TrimSpace(value) == "" {
+		return nil, fmt.Errorf("%q must be defined to use the %s discovery", envFiles, Name)
+	}
+	var patterns []string
+	for p := range strings.SplitSeq(value, check.Separator) {
+		if p = strings.TrimSpace(p); p != "" {
+			if _, err := filepath.Match(p, ""); err != nil {
+				return nil, fmt.Errorf("env var %s pattern %q is not valid: %w", envFiles, p, err)
+			}
+			patterns = append(patterns, p)
+		}
+	}
+	refresh := defaultRefreshInterval
+	if r, exists := os.LookupEnv(envRefreshInterval); exists {
+		var err error
+		if refresh, err = time.ParseDuration(r); err != nil || refresh <= 0 {
+			return nil, fmt.Errorf("env var %s %q can not be parsed as duration", envRefreshInterval, r)
+		}
+	}
+	return newDiscoverer(patterns, refresh), nil
+}
+
+func newDiscoverer(patterns []string, refresh time.Duration) *discoverer {
+	return &discoverer{patterns: patterns, refresh: refresh, files: make(map[string][]target)}
+}
+
+// discoverer reads the targets of file_sd files; the files are reloaded on changes and every refresh interval
+type discoverer struct {
+	patterns []string
+	refresh  time.Duration
+
+	mux   sync.RWMutex
+	files map[string][]target
+}
+
+func (d *discoverer) Name() string {
+	return Name
+}
+
+// Start read the files, register their label names and watch them for changes
+func (d *discoverer) Start(ctx context.Context) error {
+	log.WithFields(log.Fields{"files": d.patterns, "refresh": fmt.Sprintf("%v", d.refresh)}).Info("Starting file discovery")
+	d.reload()
+	check.RegisterTargetLabels(d.labelNames()...)
+	return discovery.Watch(ctx, d.patterns, d.refresh, d.reload)
+}
+
+// Targets the targets of all files
+func (d *discoverer) Targets() []check.Address {
+	d.mux.RLock()
+	defer d.mux.RUnlock()
+	var targets []check.Address
+	for _, f := range d.files {
+		for _, t := range f {
+			targets = append(targets, t.address)
+		}
+	}
+	return discovery.Sort(targets)
+}
+
+// reload read all files matching the patterns; the targets of a file are kept if it can not be read
+func (d *discoverer) reload() {
+	files := make(map[string][]target)
+	for _, p := range d.patterns {
+		matches, _ := filepath.Glob(p)
+		for _, f := range matches {
+			targets, err := readFile(f)
+			if err != nil {
+				log.WithField("file", f).WithError(err).Error("Error reading target file")
+				d.mux.RLock()
+				targets = d.files[f]
+				d.mux.RUnlock()
+			}
+			files[f] = targets
+		}
+	}
+	for _, f := range files {
+		for _, t := range f {
+			check.SetTargetLabels(t.address, t.labels)
+		}
+	}
+
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	d.files = files
+}
+
+func (d *discoverer) labelNames() []string {
+	d.mux.RLock()
+	defer d.mux.RUnlock()
+	var names []string
+	for _, f := range d.files {
+		for _, t := range f {
+			for n := range t.labels {
+				if !slices.Contains(names, n) && !strings.HasPrefix(n, "__") {
+					names = append(names, n)
+				}
+			}
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+func readFile(name string) ([]target, error) {
+	content, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var groups []group
+	// json is valid yaml, so both formats are read as yaml
+	if err := yaml.Unmarshal(content, &groups); err != nil {
+		return nil, fmt.Errorf("invalid file_sd format: %w", err)
+	}
+	var targets []target
+	for _, g := range groups {
+		for _, t := range g.Targets {
+			address, err := discovery.ParseAddress(t)
+			if err != nil {
+				return nil, err
+			}
+			targets = append(targets, target{address: address, labels: g.Labels})
+		}
+	}
+	return targets, nil
+}
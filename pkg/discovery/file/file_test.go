@@ -0,0 +1,100 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bakito/dns-checker/pkg/check"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+const (
+	jsonTargets = `[
+  {"targets": ["a.example.com:443", "b.example.com"], "labels": {"team": "web", "__meta_x": "y"}}
+]`
+	yamlTargets = `
+- targets:
+    - c.example.com:53
+  labels:
+    zone: a
+`
+)
+
+func Test_discoverer(t *testing.T) {
+	dir := t.TempDir()
+	write(t, filepath.Join(dir, "web.json"), jsonTargets)
+	write(t, filepath.Join(dir, "dns.yml"), yamlTargets)
+	write(t, filepath.Join(dir, "ignored.txt"), "not a target file")
+
+	d := newDiscoverer([]string{filepath.Join(dir, "*.json"), filepath.Join(dir, "*.yml")}, time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assert.Assert(t, is.Nil(d.Start(ctx)))
+
+	assert.Assert(t, is.DeepEqual(hosts(d.Targets()), []string{"a.example.com:443", "b.example.com", "c.example.com:53"}))
+	assert.Assert(t, is.DeepEqual(d.labelNames(), []string{"team", "zone"}))
+
+	// invalid files keep the previous targets
+	write(t, filepath.Join(dir, "dns.yml"), "- targets: [")
+	d.reload()
+	assert.Assert(t, is.Len(d.Targets(), 3))
+
+	// changes are picked up by the watcher
+	write(t, filepath.Join(dir, "web.json"), `[{"targets": ["d.example.com:80"]}]`)
+	expected := []string{"c.example.com:53", "d.example.com:80"}
+	for range 200 {
+		if is.DeepEqual(hosts(d.Targets()), expected)().Success() {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Assert(t, is.DeepEqual(hosts(d.Targets()), expected))
+}
+
+func Test_readFile(t *testing.T) {
+	dir := t.TempDir()
+	write(t, filepath.Join(dir, "invalid.json"), `[{"targets": ["a:b"]}]`)
+	_, err := readFile(filepath.Join(dir, "invalid.json"))
+	assert.Assert(t, is.Error(err, `port "b" of host "a" can not be parsed as int`))
+
+	write(t, filepath.Join(dir, "labels.json"), jsonTargets)
+	targets, err := readFile(filepath.Join(dir, "labels.json"))
+	assert.Assert(t, is.Nil(err))
+	assert.Assert(t, is.Len(targets, 2))
+	assert.Assert(t, is.DeepEqual(targets[0].labels, map[string]string{"team": "web", "__meta_x": "y"}))
+}
+
+func Test_New(t *testing.T) {
+	_, err := New()
+	assert.Assert(t, is.Error(err, `"FILE_SD_FILES" must be defined to use the file discovery`))
+
+	t.Setenv(envFiles, "/etc/targets/*.json, /etc/targets/*.yml")
+	d, err := New()
+	assert.Assert(t, is.Nil(err))
+	assert.Assert(t, is.DeepEqual(d.(*discoverer).patterns, []string{"/etc/targets/*.json", "/etc/targets/*.yml"}))
+
+	t.Setenv(envFiles, "/etc/targets/[.json")
+	_, err = New()
+	assert.Assert(t, is.ErrorContains(err, `env var FILE_SD_FILES pattern "/etc/targets/[.json" is not valid`))
+}
+
+func write(t *testing.T, name string, content string) {
+	assert.Assert(t, is.Nil(os.WriteFile(name, []byte(content), 0o600)))
+}
+
+func hosts(addresses []check.Address) []string {
+	var h []string
+	for _, a := range addresses {
+		if a.Port != nil {
+			h = append(h, fmt.Sprintf("%s:%d", a.Host, *a.Port))
+		} else {
+			h = append(h, a.Host)
+		}
+	}
+	return h
+}
@@ -0,0 +1,253 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/bakito/dns-checker/pkg/check"
+	"github.com/bakito/dns-checker/pkg/discovery"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	listersv1 "k8s.io/client-go/listers/core/v1"
+	listersnetworkingv1 "k8s.io/client-go/listers/networking/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	// Name the name of this discovery
+	Name = "kubernetes"
+
+	// envNamespaces a ',' separated list of namespaces to discover; all namespaces if not set
+	envNamespaces    = "KUBERNETES_NAMESPACES"
+	envLabelSelector = "KUBERNETES_LABEL_SELECTOR"
+	// envAnnotationSelector a ',' separated list of required annotations; either key=value or key to only require the annotation.
+	//  E.g: "dns-checker/enabled=true"
+	envAnnotationSelector = "KUBERNETES_ANNOTATION_SELECTOR"
+	envServices           = "KUBERNETES_SERVICES"
+	envIngresses          = "KUBERNETES_INGRESSES"
+	envClusterDomain      = "KUBERNETES_CLUSTER_DOMAIN"
+	envKubeconfig         = "KUBECONFIG"
+
+	defaultClusterDomain = "cluster.local"
+)
+
+type config struct {
+	namespaces         []string
+	labelSelector      string
+	annotationSelector map[string]*string
+	services           bool
+	ingresses          bool
+	clusterDomain      string
+}
+
+// New create a new kubernetes discovery configured by env variables; the in-cluster config is used unless KUBECONFIG is set
+func New() (discovery.Discoverer, error) {
+	cfg, err := configFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	restConfig, err := RestConfig()
+	if err != nil {
+		return nil, err
+	}
+	client, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the kubernetes client: %w", err)
+	}
+	return newDiscoverer(client, cfg), nil
+}
+
+// RestConfig the in-cluster config, or the config of KUBECONFIG if set
+func RestConfig() (*rest.Config, error) {
+	var restConfig *rest.Config
+	var err error
+	if kubeconfig, exists := os.LookupEnv(envKubeconfig); exists {
+		restConfig, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
+	} else {
+		restConfig, err = rest.InClusterConfig()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the kubernetes config: %w", err)
+	}
+	return restConfig, nil
+}
+
+func configFromEnv() (config, error) {
+	cfg := config{
+		namespaces:    []string{metav1.NamespaceAll},
+		services:      true,
+		clusterDomain: defaultClusterDomain,
+	}
+	if value, exists := os.LookupEnv(envNamespaces); exists && strings.TrimSpace(value) != "" {
+		cfg.namespaces = nil
+		for ns := range strings.SplitSeq(value, check.Separator) {
+			if ns = strings.TrimSpace(ns); ns != "" {
+				cfg.namespaces = append(cfg.namespaces, ns)
+			}
+		}
+	}
+	if value, exists := os.LookupEnv(envLabelSelector); exists {
+		if _, err := labels.Parse(value); err != nil {
+			return cfg, fmt.Errorf("env var %s %q is not a valid label selector: %w", envLabelSelector, value, err)
+		}
+		cfg.labelSelector = value
+	}
+	cfg.annotationSelector = parseAnnotationSelector(os.Getenv(envAnnotationSelector))
+	var err error
+	if value, exists := os.LookupEnv(envServices); exists {
+		if cfg.services, err = strconv.ParseBool(value); err != nil {
+			return cfg, fmt.Errorf("env var %s %q can not be parsed as bool", envServices, value)
+		}
+	}
+	if value, exists := os.LookupEnv(envIngresses); exists {
+		if cfg.ingresses, err = strconv.ParseBool(value); err != nil {
+			return cfg, fmt.Errorf("env var %s %q can not be parsed as bool", envIngresses, value)
+		}
+	}
+	if value, exists := os.LookupEnv(envClusterDomain); exists {
+		cfg.clusterDomain = strings.Trim(value, ".")
+	}
+	return cfg, nil
+}
+
+func parseAnnotationSelector(value string) map[string]*string {
+	selector := make(map[string]*string)
+	for a := range strings.SplitSeq(value, check.Separator) {
+		if a = strings.TrimSpace(a); a == "" {
+			continue
+		}
+		if k, v, ok := strings.Cut(a, "="); ok {
+			selector[strings.TrimSpace(k)] = new(strings.TrimSpace(v))
+		} else {
+			selector[a] = nil
+		}
+	}
+	return selector
+}
+
+func newDiscoverer(client kubernetes.Interface, cfg config) *discoverer {
+	return &discoverer{client: client, cfg: cfg}
+}
+
+// discoverer discovers services and ingress hosts from the informer caches
+type discoverer struct {
+	client    kubernetes.Interface
+	cfg       config
+	services  []listersv1.ServiceLister
+	ingresses []listersnetworkingv1.IngressLister
+}
+
+func (d *discoverer) Name() string {
+	return Name
+}
+
+// Start the informers and wait for the initial sync of the caches
+func (d *discoverer) Start(ctx context.Context) error {
+	var synced []cache.InformerSynced
+	for _, ns := range d.cfg.namespaces {
+		factory := informers.NewSharedInformerFactoryWithOptions(d.client, 0,
+			informers.WithNamespace(ns),
+			informers.WithTweakListOptions(func(o *metav1.ListOptions) {
+				o.LabelSelector = d.cfg.labelSelector
+			}))
+		if d.cfg.services {
+			i := factory.Core().V1().Services()
+			d.services = append(d.services, i.Lister())
+			synced = append(synced, i.Informer().HasSynced)
+		}
+		if d.cfg.ingresses {
+			i := factory.Networking().V1().Ingresses()
+			d.ingresses = append(d.ingresses, i.Lister())
+			synced = append(synced, i.Informer().HasSynced)
+		}
+		factory.Start(ctx.Done())
+	}
+
+	log.WithFields(log.Fields{
+		"namespaces": d.cfg.namespaces,
+		"selector":   d.cfg.labelSelector,
+		"services":   d.cfg.services,
+		"ingresses":  d.cfg.ingresses,
+	}).Info("Starting kubernetes discovery")
+	if !cache.WaitForCacheSync(ctx.Done(), synced...) {
+		return fmt.Errorf("failed to sync the kubernetes caches")
+	}
+	return nil
+}
+
+// Targets the services and ingress hosts matching the selectors
+func (d *discoverer) Targets() []check.Address {
+	var targets []check.Address
+	for _, l := range d.services {
+		services, err := l.List(labels.Everything())
+		if err != nil {
+			log.WithError(err).Error("Error listing services")
+			continue
+		}
+		for _, svc := range services {
+			if d.selected(svc.Annotations) {
+				targets = append(targets, d.serviceTargets(svc)...)
+			}
+		}
+	}
+	for _, l := range d.ingresses {
+		ingresses, err := l.List(labels.Everything())
+		if err != nil {
+			log.WithError(err).Error("Error listing ingresses")
+			continue
+		}
+		for _, ing := range ingresses {
+			if !d.selected(ing.Annotations) {
+				continue
+			}
+			for _, rule := range ing.Spec.Rules {
+				if rule.Host == "" || strings.HasPrefix(rule.Host, "*") {
+					continue
+				}
+				port := 80
+				for _, tls := range ing.Spec.TLS {
+					if slices.Contains(tls.Hosts, rule.Host) {
+						port = 443
+					}
+				}
+				targets = append(targets, check.Address{Host: rule.Host, Port: &port})
+			}
+		}
+	}
+	return discovery.Sort(targets)
+}
+
+// serviceTargets the cluster dns name of the service with each tcp port; without port if the service has no tcp ports
+func (d *discoverer) serviceTargets(svc *corev1.Service) []check.Address {
+	host := fmt.Sprintf("%s.%s.svc.%s", svc.Name, svc.Namespace, d.cfg.clusterDomain)
+	var targets []check.Address
+	for _, p := range svc.Spec.Ports {
+		if p.Protocol == "" || p.Protocol == corev1.ProtocolTCP {
+			targets = append(targets, check.Address{Host: host, Port: new(int(p.Port))})
+		}
+	}
+	if len(targets) == 0 {
+		targets = append(targets, check.Address{Host: host})
+	}
+	return targets
+}
+
+func (d *discoverer) selected(annotations map[string]string) bool {
+	for k, v := range d.cfg.annotationSelector {
+		actual, ok := annotations[k]
+		if !ok || (v != nil && actual != *v) {
+			return false
+		}
+	}
+	return true
+}
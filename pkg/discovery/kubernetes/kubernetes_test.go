@@ -0,0 +1,130 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bakito/dns-checker/pkg/check"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_discoverer(t *testing.T) {
+	client := fake.NewClientset(
+		service("web", "app", map[string]string{"checked": "true"}, nil,
+			corev1.ServicePort{Port: 80}, corev1.ServicePort{Port: 53, Protocol: corev1.ProtocolUDP}),
+		service("headless", "app", map[string]string{"checked": "true"}, nil),
+		service("unlabeled", "app", nil, nil, corev1.ServicePort{Port: 80}),
+		service("other", "other", map[string]string{"checked": "true"}, nil, corev1.ServicePort{Port: 80}),
+		&networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: "ing", Namespace: "app", Labels: map[string]string{"checked": "true"}},
+			Spec: networkingv1.IngressSpec{
+				TLS:   []networkingv1.IngressTLS{{Hosts: []string{"secure.example.com"}}},
+				Rules: []networkingv1.IngressRule{{Host: "secure.example.com"}, {Host: "plain.example.com"}, {Host: "*.example.com"}},
+			},
+		},
+	)
+	d := newDiscoverer(client, config{
+		namespaces:    []string{"app"},
+		labelSelector: "checked=true",
+		services:      true,
+		ingresses:     true,
+		clusterDomain: defaultClusterDomain,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assert.Assert(t, is.Nil(d.Start(ctx)))
+
+	assert.Assert(t, is.DeepEqual(hosts(d.Targets()), []string{
+		"headless.app.svc.cluster.local",
+		"plain.example.com:80",
+		"secure.example.com:443",
+		"web.app.svc.cluster.local:80",
+	}))
+
+	_, err := client.CoreV1().Services("app").Create(ctx,
+		service("new", "app", map[string]string{"checked": "true"}, nil, corev1.ServicePort{Port: 8080}), metav1.CreateOptions{})
+	assert.Assert(t, is.Nil(err))
+	assert.Assert(t, is.Nil(client.CoreV1().Services("app").Delete(ctx, "headless", metav1.DeleteOptions{})))
+
+	expected := []string{
+		"new.app.svc.cluster.local:8080",
+		"plain.example.com:80",
+		"secure.example.com:443",
+		"web.app.svc.cluster.local:80",
+	}
+	for range 100 {
+		if is.DeepEqual(hosts(d.Targets()), expected)().Success() {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Assert(t, is.DeepEqual(hosts(d.Targets()), expected))
+}
+
+func Test_discoverer_annotations(t *testing.T) {
+	client := fake.NewClientset(
+		service("a", "ns", nil, map[string]string{"dns-checker/enabled": "true"}, corev1.ServicePort{Port: 80}),
+		service("b", "ns", nil, map[string]string{"dns-checker/enabled": "false"}, corev1.ServicePort{Port: 80}),
+		service("c", "ns", nil, nil, corev1.ServicePort{Port: 80}),
+	)
+	d := newDiscoverer(client, config{
+		namespaces:         []string{metav1.NamespaceAll},
+		annotationSelector: parseAnnotationSelector("dns-checker/enabled=true"),
+		services:           true,
+		clusterDomain:      "example.local",
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assert.Assert(t, is.Nil(d.Start(ctx)))
+	assert.Assert(t, is.DeepEqual(hosts(d.Targets()), []string{"a.ns.svc.example.local:80"}))
+}
+
+func Test_configFromEnv(t *testing.T) {
+	cfg, err := configFromEnv()
+	assert.Assert(t, is.Nil(err))
+	assert.Assert(t, is.DeepEqual(cfg.namespaces, []string{metav1.NamespaceAll}))
+	assert.Assert(t, cfg.services)
+	assert.Assert(t, !cfg.ingresses)
+
+	t.Setenv(envNamespaces, "a, b")
+	t.Setenv(envIngresses, "true")
+	t.Setenv(envAnnotationSelector, "x=y,z")
+	cfg, err = configFromEnv()
+	assert.Assert(t, is.Nil(err))
+	assert.Assert(t, is.DeepEqual(cfg.namespaces, []string{"a", "b"}))
+	assert.Assert(t, cfg.ingresses)
+	assert.Assert(t, is.Equal(*cfg.annotationSelector["x"], "y"))
+	assert.Assert(t, is.Nil(cfg.annotationSelector["z"]))
+
+	t.Setenv(envLabelSelector, "a in (")
+	_, err = configFromEnv()
+	assert.Assert(t, is.ErrorContains(err, "KUBERNETES_LABEL_SELECTOR"))
+}
+
+func service(name string, namespace string, labels map[string]string, annotations map[string]string, ports ...corev1.ServicePort) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels, Annotations: annotations},
+		Spec:       corev1.ServiceSpec{Ports: ports},
+	}
+}
+
+func hosts(addresses []check.Address) []string {
+	var h []string
+	for _, a := range addresses {
+		if a.Port != nil {
+			h = append(h, fmt.Sprintf("%s:%d", a.Host, *a.Port))
+		} else {
+			h = append(h, a.Host)
+		}
+	}
+	return h
+}
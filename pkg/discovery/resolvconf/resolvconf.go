@@ -0,0 +1,197 @@
+package resolvconf
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bakito/dns-checker/pkg/check"
+	"github.com/bakito/dns-checker/pkg/check/manualdns"
+	"github.com/bakito/dns-checker/pkg/discovery"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// Name the name of this discovery
+	Name = "resolvconf"
+
+	envResolvConf = "RESOLV_CONF_PATH"
+	envHostsFile  = "HOSTS_FILE_PATH"
+	// envHostsTargets check the host names of the hosts file
+	envHostsTargets    = "HOSTS_FILE_TARGETS"
+	envRefreshInterval = "RESOLV_CONF_REFRESH_INTERVAL"
+
+	defaultResolvConf      = "/etc/resolv.conf"
+	defaultHostsFile       = "/etc/hosts"
+	defaultRefreshInterval = 5 * time.Minute
+	dnsPort                = "53"
+)
+
+// New create a new discovery of the nameservers of resolv.conf and optionally the hosts of the hosts file
+func New() (discovery.CheckDiscoverer, error) {
+	d := &discoverer{
+		resolvConf: defaultResolvConf,
+		hostsFile:  defaultHostsFile,
+		refresh:    defaultRefreshInterval,
+		checks:     make(map[string]check.Check),
+	}
+	if p, exists := os.LookupEnv(envResolvConf); exists {
+		d.resolvConf = p
+	}
+	if p, exists := os.LookupEnv(envHostsFile); exists {
+		d.hostsFile = p
+	}
+	var err error
+	if value, exists := os.LookupEnv(envHostsTargets); exists {
+		if d.hostsTargets, err = strconv.ParseBool(value); err != nil {
+			return nil, fmt.Errorf("env var %s %q can not be parsed as bool", envHostsTargets, value)
+		}
+	}
+	if value, exists := os.LookupEnv(envRefreshInterval); exists {
+		if d.refresh, err = time.ParseDuration(value); err != nil || d.refresh <= 0 {
+			return nil, fmt.Errorf("env var %s %q can not be parsed as duration", envRefreshInterval, value)
+		}
+	}
+	return d, nil
+}
+
+// ProvidesTargets the discovery provides targets; a resolvconf discovery only provides checks unless the hosts file targets are enabled
+func ProvidesTargets(d discovery.Discoverer) bool {
+	rd, ok := d.(*discoverer)
+	return !ok || rd.hostsTargets
+}
+
+// discoverer creates a manual dns check per nameserver of resolv.conf and a target per host of the hosts file
+type discoverer struct {
+	resolvConf   string
+	hostsFile    string
+	hostsTargets bool
+	refresh      time.Duration
+
+	mux         sync.RWMutex
+	nameservers []string
+	hosts       []check.Address
+	checks      map[string]check.Check
+}
+
+func (d *discoverer) Name() string {
+	return Name
+}
+
+// Start read the files and watch them for changes
+func (d *discoverer) Start(ctx context.Context) error {
+	log.WithFields(log.Fields{"resolv.conf": d.resolvConf, "hosts": d.hostsFile, "hosts-targets": d.hostsTargets}).
+		Info("Starting resolv.conf discovery")
+	d.reload()
+	files := []string{d.resolvConf}
+	if d.hostsTargets {
+		files = append(files, d.hostsFile)
+	}
+	return discovery.Watch(ctx, files, d.refresh, d.reload)
+}
+
+// Targets the hosts of the hosts file
+func (d *discoverer) Targets() []check.Address {
+	d.mux.RLock()
+	defer d.mux.RUnlock()
+	return d.hosts
+}
+
+// Checks a manual dns check per nameserver
+func (d *discoverer) Checks() []check.Check {
+	d.mux.RLock()
+	defer d.mux.RUnlock()
+	checks := make([]check.Check, 0, len(d.nameservers))
+	for _, ns := range d.nameservers {
+		checks = append(checks, d.checks[ns])
+	}
+	return checks
+}
+
+// reload read the files; the previous values are kept if a file can not be read
+func (d *discoverer) reload() {
+	nameservers, err := readNameservers(d.resolvConf)
+	if err != nil {
+		log.WithField("file", d.resolvConf).WithError(err).Error("Error reading resolv.conf")
+	}
+	var hosts []check.Address
+	if d.hostsTargets {
+		if hosts, err = readHosts(d.hostsFile); err != nil {
+			log.WithField("file", d.hostsFile).WithError(err).Error("Error reading hosts file")
+		}
+	}
+
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	if nameservers != nil {
+		d.nameservers = nameservers
+		for _, ns := range nameservers {
+			if _, ok := d.checks[ns]; !ok {
+				d.checks[ns] = manualdns.NewNamed(manualdns.Name+"@"+ns, net.JoinHostPort(ns, dnsPort))
+			}
+		}
+	}
+	if hosts != nil {
+		d.hosts = hosts
+	}
+}
+
+// readNameservers the nameservers of a resolv.conf file
+func readNameservers(name string) ([]string, error) {
+	content, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	nameservers := []string{}
+	for _, fields := range lines(content) {
+		if len(fields) > 1 && fields[0] == "nameserver" {
+			nameservers = append(nameservers, fields[1])
+		}
+	}
+	return nameservers, nil
+}
+
+// readHosts the host names of a hosts file; names of loopback, multicast and link-local addresses are skipped
+func readHosts(name string) ([]check.Address, error) {
+	content, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	hosts := []check.Address{}
+	for _, fields := range lines(content) {
+		ip := net.ParseIP(fields[0])
+		if ip == nil || !ip.IsGlobalUnicast() || len(fields) < 2 {
+			continue
+		}
+		for _, h := range fields[1:] {
+			// the ip6-* names of the default hosts file are no resolvable hosts
+			if !strings.HasPrefix(h, "ip6-") {
+				hosts = append(hosts, check.Address{Host: h})
+			}
+		}
+	}
+	return discovery.Sort(hosts), nil
+}
+
+// lines the fields of the lines without comments
+func lines(content []byte) [][]string {
+	var l [][]string
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexAny(line, "#;"); i >= 0 {
+			line = line[:i]
+		}
+		if fields := strings.Fields(line); len(fields) > 0 {
+			l = append(l, fields)
+		}
+	}
+	return l
+}
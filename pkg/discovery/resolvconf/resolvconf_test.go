@@ -0,0 +1,87 @@
+package resolvconf
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bakito/dns-checker/pkg/check"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+const (
+	resolvConf = `# generated
+search example.com
+nameserver 10.0.0.10
+nameserver 1.1.1.1 ; public
+options ndots:5
+`
+	hostsFile = `127.0.0.1	localhost
+::1	localhost ip6-localhost ip6-loopback
+fe00::0	ip6-localnet
+ff02::1	ip6-allnodes
+10.1.2.3	db.example.com db # database
+# 10.1.2.4 old.example.com
+`
+)
+
+func Test_discoverer(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(envResolvConf, write(t, filepath.Join(dir, "resolv.conf"), resolvConf))
+	t.Setenv(envHostsFile, write(t, filepath.Join(dir, "hosts"), hostsFile))
+	t.Setenv(envHostsTargets, "true")
+
+	d, err := New()
+	assert.Assert(t, is.Nil(err))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assert.Assert(t, is.Nil(d.Start(ctx)))
+
+	assert.Assert(t, is.DeepEqual(d.Targets(), []check.Address{{Host: "db"}, {Host: "db.example.com"}}))
+	checks := d.Checks()
+	assert.Assert(t, is.DeepEqual(names(checks), []string{"manual_dns@10.0.0.10", "manual_dns@1.1.1.1"}))
+
+	// unchanged nameservers keep their check
+	write(t, filepath.Join(dir, "resolv.conf"), "nameserver 1.1.1.1\nnameserver 8.8.8.8\n")
+	d.(*discoverer).reload()
+	assert.Assert(t, is.DeepEqual(names(d.Checks()), []string{"manual_dns@1.1.1.1", "manual_dns@8.8.8.8"}))
+	assert.Assert(t, d.Checks()[0] == checks[1])
+
+	// missing files keep the previous values
+	assert.Assert(t, is.Nil(os.Remove(filepath.Join(dir, "hosts"))))
+	d.(*discoverer).reload()
+	assert.Assert(t, is.Len(d.Targets(), 2))
+}
+
+func Test_New_hostsDisabled(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(envResolvConf, write(t, filepath.Join(dir, "resolv.conf"), resolvConf))
+	t.Setenv(envHostsFile, write(t, filepath.Join(dir, "hosts"), hostsFile))
+
+	d, err := New()
+	assert.Assert(t, is.Nil(err))
+	d.(*discoverer).reload()
+	assert.Assert(t, is.Len(d.Targets(), 0))
+	assert.Assert(t, is.Len(d.Checks(), 2))
+	assert.Assert(t, !ProvidesTargets(d))
+
+	t.Setenv(envHostsTargets, "maybe")
+	_, err = New()
+	assert.Assert(t, is.Error(err, `env var HOSTS_FILE_TARGETS "maybe" can not be parsed as bool`))
+}
+
+func names(checks []check.Check) []string {
+	var n []string
+	for _, c := range checks {
+		n = append(n, c.Name())
+	}
+	return n
+}
+
+func write(t *testing.T, name string, content string) string {
+	t.Helper()
+	assert.Assert(t, is.Nil(os.WriteFile(name, []byte(content), 0o600)))
+	return name
+}
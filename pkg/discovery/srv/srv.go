@@ -0,0 +1,127 @@
+package srv
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bakito/dns-checker/pkg/check"
+	"github.com/bakito/dns-checker/pkg/discovery"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// Name the name of this discovery
+	Name = "srv"
+	// Prefix the target prefix of srv records. E.g: "srv+_http._tcp.example.com"
+	Prefix = "srv+"
+
+	envSRVRefreshInterval = "SRV_REFRESH_INTERVAL"
+
+	defaultRefreshInterval = 30 * time.Second
+
+	labelRecord   = "srv_record"
+	labelPriority = "srv_priority"
+	labelWeight   = "srv_weight"
+)
+
+// LabelNames the target labels set for the discovered targets
+var LabelNames = []string{labelRecord, labelPriority, labelWeight}
+
+type resolver interface {
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+}
+
+// New create a new discovery of the answers of the srv record
+func New(record string) (discovery.Discoverer, error) {
+	record = strings.TrimSpace(strings.TrimPrefix(record, Prefix))
+	if record == "" {
+		return nil, fmt.Errorf("srv target %q has no record name", Prefix)
+	}
+	refresh := defaultRefreshInterval
+	if value, exists := os.LookupEnv(envSRVRefreshInterval); exists {
+		var err error
+		if refresh, err = time.ParseDuration(value); err != nil || refresh <= 0 {
+			return nil, fmt.Errorf("env var %s %q can not be parsed as duration", envSRVRefreshInterval, value)
+		}
+	}
+	return newDiscoverer(record, refresh, net.DefaultResolver), nil
+}
+
+func newDiscoverer(record string, refresh time.Duration, r resolver) *discoverer {
+	return &discoverer{record: record, refresh: refresh, resolver: r}
+}
+
+// discoverer periodically resolves a srv record into one target per answer
+type discoverer struct {
+	record   string
+	refresh  time.Duration
+	resolver resolver
+
+	mux     sync.RWMutex
+	targets []check.Address
+}
+
+func (d *discoverer) Name() string {
+	return Name
+}
+
+// Start resolve the record and refresh it every interval; a failing initial lookup is logged and retried on the next refresh
+func (d *discoverer) Start(ctx context.Context) error {
+	log.WithFields(log.Fields{"record": d.record, "refresh": fmt.Sprintf("%v", d.refresh)}).Info("Starting srv discovery")
+	d.lookup(ctx)
+	go func() {
+		ticker := time.NewTicker(d.refresh)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				d.lookup(ctx)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// Targets the targets of the latest successful lookup
+func (d *discoverer) Targets() []check.Address {
+	d.mux.RLock()
+	defer d.mux.RUnlock()
+	return d.targets
+}
+
+// lookup resolve the record; the previous targets are kept if the lookup fails
+func (d *discoverer) lookup(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, d.refresh)
+	defer cancel()
+	_, records, err := d.resolver.LookupSRV(ctx, "", "", d.record)
+	if err != nil {
+		log.WithField("record", d.record).WithError(err).Warn("Error resolving srv record")
+		return
+	}
+
+	var targets []check.Address
+	for _, r := range records {
+		address := check.Address{Host: strings.TrimSuffix(r.Target, "."), Port: new(int(r.Port))}
+		check.SetTargetLabels(address, map[string]string{
+			labelRecord:   d.record,
+			labelPriority: strconv.Itoa(int(r.Priority)),
+			labelWeight:   strconv.Itoa(int(r.Weight)),
+		})
+		targets = append(targets, address)
+	}
+	targets = discovery.Sort(targets)
+
+	log.WithFields(log.Fields{"record": d.record, "targets": len(targets)}).Debug("Resolved srv record")
+
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	d.targets = targets
+}
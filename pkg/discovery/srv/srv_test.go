@@ -0,0 +1,67 @@
+package srv
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/bakito/dns-checker/pkg/check"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func Test_discoverer(t *testing.T) {
+	r := &testResolver{records: []*net.SRV{
+		{Target: "b.example.com.", Port: 8080, Priority: 10, Weight: 5},
+		{Target: "a.example.com.", Port: 8080, Priority: 20, Weight: 1},
+	}}
+	d := newDiscoverer("_http._tcp.example.com", time.Hour, r)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assert.Assert(t, is.Nil(d.Start(ctx)))
+	assert.Assert(t, is.Equal(r.name, "_http._tcp.example.com"))
+
+	port := 8080
+	assert.Assert(t, is.DeepEqual(d.Targets(), []check.Address{
+		{Host: "a.example.com", Port: &port},
+		{Host: "b.example.com", Port: &port},
+	}))
+
+	// failed lookups keep the previous targets
+	r.err = errors.New("timeout")
+	d.lookup(ctx)
+	assert.Assert(t, is.Len(d.Targets(), 2))
+
+	r.err = nil
+	r.records = r.records[:1]
+	d.lookup(ctx)
+	assert.Assert(t, is.DeepEqual(d.Targets(), []check.Address{{Host: "b.example.com", Port: &port}}))
+}
+
+func Test_New(t *testing.T) {
+	d, err := New("srv+_http._tcp.example.com")
+	assert.Assert(t, is.Nil(err))
+	assert.Assert(t, is.Equal(d.(*discoverer).record, "_http._tcp.example.com"))
+	assert.Assert(t, is.Equal(d.(*discoverer).refresh, defaultRefreshInterval))
+
+	_, err = New("srv+")
+	assert.Assert(t, is.Error(err, `srv target "srv+" has no record name`))
+
+	t.Setenv(envSRVRefreshInterval, "foo")
+	_, err = New("srv+_http._tcp.example.com")
+	assert.Assert(t, is.Error(err, `env var SRV_REFRESH_INTERVAL "foo" can not be parsed as duration`))
+}
+
+type testResolver struct {
+	name    string
+	records []*net.SRV
+	err     error
+}
+
+func (r *testResolver) LookupSRV(_ context.Context, _, _, name string) (string, []*net.SRV, error) {
+	r.name = name
+	return "", r.records, r.err
+}
@@ -0,0 +1,61 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	log "github.com/sirupsen/logrus"
+)
+
+// Watch call reload when a file matching the patterns changes and every refresh interval until the context is done.
+// The directories of the patterns are watched, to also notice files that are replaced.
+func Watch(ctx context.Context, patterns []string, refresh time.Duration, reload func()) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create file watcher: %w", err)
+	}
+	var dirs []string
+	for _, p := range patterns {
+		if dir := filepath.Dir(p); !slices.Contains(dirs, dir) {
+			dirs = append(dirs, dir)
+			if err := watcher.Add(dir); err != nil {
+				log.WithField("dir", dir).WithError(err).Warn("Error watching directory, falling back to the refresh interval")
+			}
+		}
+	}
+
+	go func() {
+		defer func() {
+			_ = watcher.Close()
+		}()
+		ticker := time.NewTicker(refresh)
+		defer ticker.Stop()
+		for {
+			select {
+			case e := <-watcher.Events:
+				if matches(patterns, e.Name) {
+					log.WithFields(log.Fields{"file": e.Name, "op": e.Op.String()}).Debug("Watched file changed")
+					reload()
+				}
+			case err := <-watcher.Errors:
+				log.WithError(err).Warn("Error watching files")
+			case <-ticker.C:
+				reload()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+func matches(patterns []string, name string) bool {
+	return slices.ContainsFunc(patterns, func(p string) bool {
+		ok, _ := filepath.Match(p, name)
+		return ok
+	})
+}
@@ -0,0 +1,184 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bakito/dns-checker/pkg/check"
+	log "github.com/sirupsen/logrus"
+)
+
+// subscriberBuffer the number of events buffered per subscriber; events are dropped for slow subscribers
+const subscriberBuffer = 100
+
+var defaultBroker = NewBroker()
+
+// Event a check result
+type Event struct {
+	Target    string      `json:"target"`
+	Port      *int        `json:"port,omitempty"`
+	Check     string      `json:"check"`
+	OK        bool        `json:"ok"`
+	Error     string      `json:"error,omitempty"`
+	Reason    string      `json:"reason,omitempty"`
+	Duration  float64     `json:"duration"`
+	TimedOut  bool        `json:"timedOut"`
+	WorkerID  int         `json:"worker"`
+	State     check.State `json:"state"`
+	Timestamp time.Time   `json:"timestamp"`
+}
+
+// New create a new event from a check result; the duration is converted to milliseconds
+func New(name string, address check.Address, result check.Result, state check.State) Event {
+	e := Event{
+		Target:    address.Host,
+		Port:      address.Port,
+		Check:     name,
+		OK:        result.Err == nil,
+		TimedOut:  result.TimedOut,
+		WorkerID:  result.WorkerID,
+		State:     state,
+		Timestamp: time.Now(),
+	}
+	if result.Err != nil {
+		e.Error = result.Err.Error()
+		e.Reason = string(check.Classify(result))
+	}
+	if result.Duration != nil {
+		e.Duration = float64(*result.Duration) / float64(time.Millisecond)
+	}
+	return e
+}
+
+// Publish publish the event to all subscribers of the default broker
+func Publish(e Event) {
+	defaultBroker.Publish(e)
+}
+
+// Handler the http handler of the default broker
+func Handler() http.Handler {
+	return defaultBroker
+}
+
+// NewBroker create a new event broker
+func NewBroker() *Broker {
+	return &Broker{subscribers: make(map[*subscriber]bool)}
+}
+
+// Broker distributes events to subscribers
+type Broker struct {
+	lock        sync.RWMutex
+	subscribers map[*subscriber]bool
+}
+
+type filter struct {
+	target       string
+	check        string
+	failuresOnly bool
+}
+
+func (f filter) matches(e Event) bool {
+	if f.failuresOnly && e.OK {
+		return false
+	}
+	if f.check != "" && f.check != e.Check {
+		return false
+	}
+	if f.target != "" && f.target != e.Target && (e.Port == nil || f.target != fmt.Sprintf("%s:%d", e.Target, *e.Port)) {
+		return false
+	}
+	return true
+}
+
+type subscriber struct {
+	filter  filter
+	events  chan Event
+	dropped atomic.Int64
+}
+
+// Publish the event to all matching subscribers. Never blocks; if a subscriber is too slow, the event is dropped for it.
+func (b *Broker) Publish(e Event) {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	for s := range b.subscribers {
+		if !s.filter.matches(e) {
+			continue
+		}
+		select {
+		case s.events <- e:
+		default:
+			s.dropped.Add(1)
+		}
+	}
+}
+
+func (b *Broker) subscribe(f filter) *subscriber {
+	s := &subscriber{filter: f, events: make(chan Event, subscriberBuffer)}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.subscribers[s] = true
+	return s
+}
+
+func (b *Broker) unsubscribe(s *subscriber) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	delete(b.subscribers, s)
+	if dropped := s.dropped.Load(); dropped > 0 {
+		log.WithField("dropped", dropped).Info("Slow event subscriber dropped events")
+	}
+}
+
+// ServeHTTP stream the events as server-sent events, filtered by the query parameters target, check and failures
+func (b *Broker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	q := r.URL.Query()
+	f := filter{target: q.Get("target"), check: q.Get("check")}
+	if failures := q.Get("failures"); failures != "" {
+		var err error
+		if f.failuresOnly, err = strconv.ParseBool(failures); err != nil {
+			http.Error(w, fmt.Sprintf("invalid failures %q", failures), http.StatusBadRequest)
+			return
+		}
+	}
+
+	s := b.subscribe(f)
+	defer b.unsubscribe(s)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case e := <-s.events:
+			data, err := json.Marshal(e)
+			if err != nil {
+				log.WithError(err).Error("Error marshalling event")
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: result\ndata: %s\n\n", data); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package events
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bakito/dns-checker/pkg/check"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func Test_filter_matches(t *testing.T) {
+	port := 443
+	ok := New("dns", check.Address{Host: "a", Port: &port}, check.Result{}, check.StateUp)
+	nok := New("probe-port", check.Address{Host: "b"}, check.Result{Err: errors.New("failed")}, check.StateDown)
+
+	assert.Assert(t, filter{}.matches(ok))
+	assert.Assert(t, filter{target: "a"}.matches(ok))
+	assert.Assert(t, filter{target: "a:443"}.matches(ok))
+	assert.Assert(t, !filter{target: "a:80"}.matches(ok))
+	assert.Assert(t, !filter{target: "a"}.matches(nok))
+	assert.Assert(t, filter{check: "dns"}.matches(ok))
+	assert.Assert(t, !filter{check: "dns"}.matches(nok))
+	assert.Assert(t, !filter{failuresOnly: true}.matches(ok))
+	assert.Assert(t, filter{failuresOnly: true}.matches(nok))
+}
+
+func Test_Broker_slowSubscriber(t *testing.T) {
+	b := NewBroker()
+	s := b.subscribe(filter{})
+	for range subscriberBuffer + 5 {
+		b.Publish(Event{})
+	}
+	assert.Assert(t, is.Len(s.events, subscriberBuffer))
+	assert.Assert(t, is.Equal(s.dropped.Load(), int64(5)))
+	b.unsubscribe(s)
+	assert.Assert(t, is.Len(b.subscribers, 0))
+}
+
+func Test_Broker_ServeHTTP(t *testing.T) {
+	b := NewBroker()
+	srv := httptest.NewServer(b)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "?failures=true")
+	assert.Assert(t, is.Nil(err))
+	defer func() { _ = resp.Body.Close() }()
+	assert.Assert(t, is.Equal(resp.Header.Get("Content-Type"), "text/event-stream"))
+
+	for b.count() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	b.Publish(New("dns", check.Address{Host: "a"}, check.Result{}, check.StateUp))
+	b.Publish(New("dns", check.Address{Host: "b"}, check.Result{Err: errors.New("failed")}, check.StateDown))
+
+	reader := bufio.NewReader(resp.Body)
+	line, err := reader.ReadString('\n')
+	assert.Assert(t, is.Nil(err))
+	assert.Assert(t, is.Equal(line, "event: result\n"))
+	line, err = reader.ReadString('\n')
+	assert.Assert(t, is.Nil(err))
+
+	var e Event
+	assert.Assert(t, is.Nil(json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &e)))
+	assert.Assert(t, is.Equal(e.Target, "b"))
+	assert.Assert(t, is.Equal(e.Error, "failed"))
+	assert.Assert(t, is.Equal(e.Reason, "unknown"))
+	assert.Assert(t, is.Equal(e.State, check.StateDown))
+}
+
+func Test_Broker_ServeHTTP_invalidFilter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NewBroker().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/events?failures=maybe", nil))
+	assert.Assert(t, is.Equal(rec.Code, http.StatusBadRequest))
+}
+
+func (b *Broker) count() int {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	return len(b.subscribers)
+}
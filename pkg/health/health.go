@@ -0,0 +1,165 @@
+package health
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// envMaxMissedIntervals the number of intervals without activity of the scheduler or result handler until the liveness fails
+	envMaxMissedIntervals = "HEALTH_MAX_MISSED_INTERVALS"
+
+	defaultMaxMissedIntervals = 3
+)
+
+var defaultHealth = New()
+
+// Start start the health tracking of the default health
+func Start(interval time.Duration) {
+	maxMissed := defaultMaxMissedIntervals
+	if value, exists := os.LookupEnv(envMaxMissedIntervals); exists {
+		if m, err := strconv.Atoi(value); err == nil && m > 0 {
+			maxMissed = m
+		} else {
+			log.WithFields(log.Fields{"env": envMaxMissedIntervals, "value": value, "default": defaultMaxMissedIntervals}).
+				Warn("could not parse the max missed intervals, using the default")
+		}
+	}
+	defaultHealth.Start(interval, maxMissed)
+}
+
+// Scheduled record a completed scheduler run of n checks in the default health
+func Scheduled(n int) {
+	defaultHealth.Scheduled(n)
+}
+
+// Processed record a processed result in the default health
+func Processed() {
+	defaultHealth.Processed()
+}
+
+// Ready mark the default health as ready
+func Ready() {
+	defaultHealth.Ready()
+}
+
+// LivenessHandler the liveness http handler of the default health
+func LivenessHandler() http.Handler {
+	return http.HandlerFunc(defaultHealth.ServeLiveness)
+}
+
+// ReadinessHandler the readiness http handler of the default health
+func ReadinessHandler() http.Handler {
+	return http.HandlerFunc(defaultHealth.ServeReadiness)
+}
+
+// New create a new health
+func New() *Health {
+	return &Health{now: time.Now}
+}
+
+// Health tracks the activity of the scheduler and result handler
+type Health struct {
+	lock          sync.RWMutex
+	now           func() time.Time
+	maxAge        time.Duration
+	lastScheduled time.Time
+	lastProcessed time.Time
+	// scheduled the number of checks of the last scheduler run
+	scheduled int
+	// scheduledSince the time checks are scheduled since the last run without checks
+	scheduledSince time.Time
+	ready          bool
+}
+
+// Start the tracking; liveness fails if there was no activity for maxMissed intervals
+func (h *Health) Start(interval time.Duration, maxMissed int) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	// the first run is scheduled after one interval
+	h.maxAge = interval * time.Duration(maxMissed+1)
+	h.lastScheduled = h.now()
+}
+
+// Scheduled record a completed scheduler run of n checks
+func (h *Health) Scheduled(n int) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.lastScheduled = h.now()
+	if n > 0 && h.scheduled == 0 {
+		h.scheduledSince = h.lastScheduled
+	}
+	h.scheduled = n
+}
+
+// Processed record a processed result
+func (h *Health) Processed() {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.lastProcessed = h.now()
+}
+
+// Ready mark as ready
+func (h *Health) Ready() {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if !h.ready {
+		log.Info("First round of checks completed")
+	}
+	h.ready = true
+}
+
+// Alive check if the scheduler and result handler are alive
+func (h *Health) Alive() error {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	if h.lastScheduled.IsZero() {
+		return fmt.Errorf("scheduler not started")
+	}
+	now := h.now()
+	if age := now.Sub(h.lastScheduled); age > h.maxAge {
+		return fmt.Errorf("no checks scheduled since %v", age.Round(time.Second))
+	}
+	// no results are expected while no checks are scheduled, e.g. if all discovered targets are removed
+	if h.scheduled > 0 && !h.lastProcessed.IsZero() {
+		last := h.lastProcessed
+		if h.scheduledSince.After(last) {
+			last = h.scheduledSince
+		}
+		if age := now.Sub(last); age > h.maxAge {
+			return fmt.Errorf("no results processed since %v", age.Round(time.Second))
+		}
+	}
+	return nil
+}
+
+// IsReady check if the first round of checks has completed
+func (h *Health) IsReady() bool {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	return h.ready
+}
+
+// ServeLiveness serve the liveness state
+func (h *Health) ServeLiveness(w http.ResponseWriter, _ *http.Request) {
+	if err := h.Alive(); err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	_, _ = w.Write([]byte("ok"))
+}
+
+// ServeReadiness serve the readiness state
+func (h *Health) ServeReadiness(w http.ResponseWriter, _ *http.Request) {
+	if !h.IsReady() {
+		http.Error(w, "first round of checks not completed", http.StatusServiceUnavailable)
+		return
+	}
+	_, _ = w.Write([]byte("ok"))
+}
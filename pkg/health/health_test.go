@@ -0,0 +1,66 @@
+package health
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func Test_Health_Alive(t *testing.T) {
+	now := time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC)
+	h := New()
+	h.now = func() time.Time { return now }
+
+	assert.Assert(t, is.Error(h.Alive(), "scheduler not started"))
+
+	h.Start(10*time.Second, 3)
+	assert.Assert(t, is.Nil(h.Alive()))
+
+	now = now.Add(41 * time.Second)
+	assert.Assert(t, is.Error(h.Alive(), "no checks scheduled since 41s"))
+
+	h.Scheduled(2)
+	h.Processed()
+	assert.Assert(t, is.Nil(h.Alive()))
+
+	now = now.Add(30 * time.Second)
+	h.Scheduled(2)
+	now = now.Add(15 * time.Second)
+	assert.Assert(t, is.Error(h.Alive(), "no results processed since 45s"))
+
+	// no results are expected without scheduled checks
+	h.Scheduled(0)
+	assert.Assert(t, is.Nil(h.Alive()))
+	now = now.Add(time.Hour)
+	h.Scheduled(0)
+	assert.Assert(t, is.Nil(h.Alive()))
+
+	// the results are expected since the checks are scheduled again
+	h.Scheduled(1)
+	assert.Assert(t, is.Nil(h.Alive()))
+	now = now.Add(41 * time.Second)
+	h.Scheduled(1)
+	assert.Assert(t, is.Error(h.Alive(), "no results processed since 41s"))
+}
+
+func Test_Health_Handlers(t *testing.T) {
+	h := New()
+	h.Start(time.Minute, 3)
+
+	rec := httptest.NewRecorder()
+	h.ServeLiveness(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Assert(t, is.Equal(rec.Code, http.StatusOK))
+
+	rec = httptest.NewRecorder()
+	h.ServeReadiness(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Assert(t, is.Equal(rec.Code, http.StatusServiceUnavailable))
+
+	h.Ready()
+	rec = httptest.NewRecorder()
+	h.ServeReadiness(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Assert(t, is.Equal(rec.Code, http.StatusOK))
+}
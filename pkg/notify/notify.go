@@ -0,0 +1,47 @@
+package notify
+
+import (
+	"context"
+	"time"
+
+	"github.com/bakito/dns-checker/pkg/check"
+)
+
+// Notification a state change of a target check
+type Notification struct {
+	Target        string      `json:"target"`
+	Port          *int        `json:"port,omitempty"`
+	Check         string      `json:"check"`
+	Error         string      `json:"error,omitempty"`
+	Duration      float64     `json:"duration"`
+	PreviousState check.State `json:"previousState"`
+	State         check.State `json:"state"`
+	Timestamp     time.Time   `json:"timestamp"`
+}
+
+// Notifier sends notifications
+type Notifier interface {
+	// Start the notifier, it stops when the context is done
+	Start(ctx context.Context)
+	// Notify queue a notification, must not block
+	Notify(n Notification)
+}
+
+// New create a new notification from a check result; the duration is converted to milliseconds
+func New(name string, address check.Address, result check.Result, previous check.State, current check.State) Notification {
+	n := Notification{
+		Target:        address.Host,
+		Port:          address.Port,
+		Check:         name,
+		PreviousState: previous,
+		State:         current,
+		Timestamp:     time.Now(),
+	}
+	if result.Err != nil {
+		n.Error = result.Err.Error()
+	}
+	if result.Duration != nil {
+		n.Duration = float64(*result.Duration) / float64(time.Millisecond)
+	}
+	return n
+}
@@ -0,0 +1,192 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/bakito/dns-checker/pkg/check"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// envWebhookURLs a ',' separated list of urls the notifications are posted to
+	envWebhookURLs = "WEBHOOK_URLS"
+	// envWebhookTemplate a go template to render the request body. The notification is passed as data.
+	//  E.g: '{"text":"{{ .Target }} {{ .Check }} is {{ .State }}"}'
+	envWebhookTemplate    = "WEBHOOK_TEMPLATE"
+	envWebhookContentType = "WEBHOOK_CONTENT_TYPE"
+	envWebhookRetries     = "WEBHOOK_RETRIES"
+	envWebhookRetryDelay  = "WEBHOOK_RETRY_DELAY"
+	envWebhookTimeout     = "WEBHOOK_TIMEOUT"
+
+	defaultContentType = "application/json"
+	queueSize          = 100
+)
+
+// NewWebhookFromEnv create a new webhook notifier configured by env variables.
+// Returns nil if no webhook urls are defined.
+func NewWebhookFromEnv() (*Webhook, error) {
+	value, exists := os.LookupEnv(envWebhookURLs)
+	if !exists || strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+	var urls []string
+	for u := range strings.SplitSeq(value, check.Separator) {
+		if u = strings.TrimSpace(u); u != "" {
+			urls = append(urls, u)
+		}
+	}
+
+	w, err := NewWebhook(urls, os.Getenv(envWebhookTemplate))
+	if err != nil {
+		return nil, err
+	}
+
+	if ct, exists := os.LookupEnv(envWebhookContentType); exists {
+		w.contentType = ct
+	}
+	if r, exists := os.LookupEnv(envWebhookRetries); exists {
+		if w.retries, err = strconv.Atoi(r); err != nil {
+			return nil, fmt.Errorf("env var %s %q can not be parsed as int", envWebhookRetries, r)
+		}
+	}
+	if d, exists := os.LookupEnv(envWebhookRetryDelay); exists {
+		if w.retryDelay, err = time.ParseDuration(d); err != nil {
+			return nil, fmt.Errorf("env var %s %q can not be parsed as duration", envWebhookRetryDelay, d)
+		}
+	}
+	if to, exists := os.LookupEnv(envWebhookTimeout); exists {
+		if w.client.Timeout, err = time.ParseDuration(to); err != nil {
+			return nil, fmt.Errorf("env var %s %q can not be parsed as duration", envWebhookTimeout, to)
+		}
+	}
+	return w, nil
+}
+
+// NewWebhook create a new webhook notifier posting to the given urls. If the body template is empty, the notification is sent as json.
+func NewWebhook(urls []string, bodyTemplate string) (*Webhook, error) {
+	w := &Webhook{
+		urls:        urls,
+		contentType: defaultContentType,
+		retries:     3,
+		retryDelay:  time.Second,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		queue:       make(chan Notification, queueSize),
+	}
+	if bodyTemplate != "" {
+		t, err := template.New("webhook").Funcs(template.FuncMap{"json": toJSON}).Parse(bodyTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("webhook template can not be parsed: %w", err)
+		}
+		w.template = t
+	}
+	return w, nil
+}
+
+// Webhook posts notifications to urls
+type Webhook struct {
+	urls        []string
+	template    *template.Template
+	contentType string
+	retries     int
+	retryDelay  time.Duration
+	client      *http.Client
+	queue       chan Notification
+}
+
+// Start sending the queued notifications
+func (w *Webhook) Start(ctx context.Context) {
+	log.WithField("urls", w.urls).Info("Starting webhook notifier")
+	go func() {
+		for {
+			select {
+			case n := <-w.queue:
+				w.send(ctx, n)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Notify queue the notification. If the queue is full, the notification is dropped.
+func (w *Webhook) Notify(n Notification) {
+	select {
+	case w.queue <- n:
+	default:
+		log.WithFields(log.Fields{"target": n.Target, "name": n.Check}).Warn("Webhook queue is full, dropping notification")
+	}
+}
+
+func (w *Webhook) send(ctx context.Context, n Notification) {
+	body, err := w.body(n)
+	if err != nil {
+		log.WithError(err).Error("Error rendering webhook body")
+		return
+	}
+	for _, u := range w.urls {
+		if err := w.post(ctx, u, body); err != nil {
+			log.WithField("url", u).WithError(err).Error("Error sending webhook notification")
+		}
+	}
+}
+
+func (w *Webhook) body(n Notification) ([]byte, error) {
+	if w.template == nil {
+		return json.Marshal(n)
+	}
+	var buf bytes.Buffer
+	if err := w.template.Execute(&buf, n); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// post the body to the url, retrying on errors and non 2xx responses
+func (w *Webhook) post(ctx context.Context, url string, body []byte) error {
+	var err error
+	for attempt := 0; attempt <= w.retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(w.retryDelay):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		if err = w.postOnce(ctx, url, body); err == nil {
+			return nil
+		}
+		log.WithFields(log.Fields{"url": url, "attempt": attempt + 1}).WithError(err).Debug("Webhook request failed")
+	}
+	return err
+}
+
+func (w *Webhook) postOnce(ctx context.Context, url string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", w.contentType)
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response status %q", resp.Status)
+	}
+	return nil
+}
+
+func toJSON(v any) (string, error) {
+	b, err := json.Marshal(v)
+	return string(b), err
+}
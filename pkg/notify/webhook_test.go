@@ -0,0 +1,105 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bakito/dns-checker/pkg/check"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func Test_Webhook_send(t *testing.T) {
+	var bodies [][]byte
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		assert.Assert(t, is.Equal(r.Header.Get("Content-Type"), defaultContentType))
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, b)
+	}))
+	defer srv.Close()
+
+	w, err := NewWebhook([]string{srv.URL}, "")
+	assert.Assert(t, is.Nil(err))
+	w.retryDelay = time.Millisecond
+
+	port := 53
+	d := 1500 * time.Microsecond
+	n := New("dns", check.Address{Host: "host.name", Port: &port},
+		check.Result{Err: errors.New("failed"), Duration: &d}, check.StateUp, check.StateDown)
+	w.send(context.Background(), n)
+
+	assert.Assert(t, is.Equal(calls, 2))
+	assert.Assert(t, is.Len(bodies, 1))
+
+	var received Notification
+	assert.Assert(t, is.Nil(json.Unmarshal(bodies[0], &received)))
+	assert.Assert(t, is.Equal(received.Target, "host.name"))
+	assert.Assert(t, is.Equal(*received.Port, 53))
+	assert.Assert(t, is.Equal(received.Check, "dns"))
+	assert.Assert(t, is.Equal(received.Error, "failed"))
+	assert.Assert(t, is.Equal(received.Duration, 1.5))
+	assert.Assert(t, is.Equal(received.PreviousState, check.StateUp))
+	assert.Assert(t, is.Equal(received.State, check.StateDown))
+}
+
+func Test_Webhook_retries(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	w, err := NewWebhook([]string{srv.URL}, "")
+	assert.Assert(t, is.Nil(err))
+	w.retries = 2
+	w.retryDelay = time.Millisecond
+
+	err = w.post(context.Background(), srv.URL, []byte("{}"))
+	assert.Assert(t, is.Error(err, `unexpected response status "500 Internal Server Error"`))
+	assert.Assert(t, is.Equal(calls, 3))
+}
+
+func Test_Webhook_template(t *testing.T) {
+	w, err := NewWebhook(nil, `{"text":"{{ .Target }} {{ .Check }} is {{ .State }}","error":{{ json .Error }}}`)
+	assert.Assert(t, is.Nil(err))
+
+	b, err := w.body(Notification{Target: "host.name", Check: "dns", State: check.StateDown, Error: `"quoted"`})
+	assert.Assert(t, is.Nil(err))
+	assert.Assert(t, is.Equal(string(b), `{"text":"host.name dns is down","error":"\"quoted\""}`))
+
+	_, err = NewWebhook(nil, "{{ .Target ")
+	assert.Assert(t, is.ErrorContains(err, "webhook template can not be parsed"))
+}
+
+func Test_NewWebhookFromEnv(t *testing.T) {
+	t.Setenv(envWebhookURLs, "")
+	w, err := NewWebhookFromEnv()
+	assert.Assert(t, is.Nil(err))
+	assert.Assert(t, w == nil)
+
+	t.Setenv(envWebhookURLs, "http://a, http://b")
+	t.Setenv(envWebhookRetries, "5")
+	t.Setenv(envWebhookRetryDelay, "2s")
+	w, err = NewWebhookFromEnv()
+	assert.Assert(t, is.Nil(err))
+	assert.Assert(t, is.DeepEqual(w.urls, []string{"http://a", "http://b"}))
+	assert.Assert(t, is.Equal(w.retries, 5))
+	assert.Assert(t, is.Equal(w.retryDelay, 2*time.Second))
+
+	t.Setenv(envWebhookRetries, "x")
+	_, err = NewWebhookFromEnv()
+	assert.Assert(t, is.Error(err, `env var WEBHOOK_RETRIES "x" can not be parsed as int`))
+}
@@ -0,0 +1,178 @@
+package push
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bakito/dns-checker/pkg/check"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// envPushMode the push mode (pushgateway, remote-write); push is disabled if not set
+	envPushMode = "PUSH_MODE"
+	// envPushURL the url of the pushgateway or the remote-write endpoint
+	envPushURL      = "PUSH_URL"
+	envPushInterval = "PUSH_INTERVAL"
+	envPushJob      = "PUSH_JOB"
+	// envPushLabels a ',' separated list of '=' separated labels; used as grouping key for the pushgateway
+	// and added to all series for remote-write. E.g: "cluster=prod,zone=a"
+	envPushLabels     = "PUSH_LABELS"
+	envPushRetries    = "PUSH_RETRIES"
+	envPushRetryDelay = "PUSH_RETRY_DELAY"
+	envPushTimeout    = "PUSH_TIMEOUT"
+
+	// ModePushgateway push the metrics to a prometheus pushgateway
+	ModePushgateway = "pushgateway"
+	// ModeRemoteWrite send the metrics via the prometheus remote-write protocol
+	ModeRemoteWrite = "remote-write"
+
+	defaultJob = "dns_checker"
+)
+
+type sender interface {
+	send(ctx context.Context) error
+}
+
+// permanentError an error of a send that is not retried
+type permanentError struct {
+	error
+}
+
+func (e permanentError) Unwrap() error {
+	return e.error
+}
+
+// Pusher periodically pushes the metrics of a gatherer
+type Pusher struct {
+	mode       string
+	url        string
+	interval   time.Duration
+	retries    int
+	retryDelay time.Duration
+	sender     sender
+}
+
+// FromEnv create a new pusher configured by env variables pushing the metrics of the gatherer.
+// Returns nil if push is not enabled.
+func FromEnv(gatherer prometheus.Gatherer, defaultInterval time.Duration) (*Pusher, error) {
+	mode, exists := os.LookupEnv(envPushMode)
+	if !exists || strings.TrimSpace(mode) == "" {
+		return nil, nil
+	}
+	url, exists := os.LookupEnv(envPushURL)
+	if !exists {
+		return nil, fmt.Errorf("%q must be defined to use push mode %s", envPushURL, mode)
+	}
+
+	p := &Pusher{
+		mode:       strings.TrimSpace(mode),
+		url:        url,
+		interval:   defaultInterval,
+		retries:    3,
+		retryDelay: time.Second,
+	}
+	var err error
+	if i, exists := os.LookupEnv(envPushInterval); exists {
+		if p.interval, err = time.ParseDuration(i); err != nil || p.interval <= 0 {
+			return nil, fmt.Errorf("env var %s %q must be a positive duration", envPushInterval, i)
+		}
+	}
+	if r, exists := os.LookupEnv(envPushRetries); exists {
+		if p.retries, err = strconv.Atoi(r); err != nil {
+			return nil, fmt.Errorf("env var %s %q can not be parsed as int", envPushRetries, r)
+		}
+	}
+	if d, exists := os.LookupEnv(envPushRetryDelay); exists {
+		if p.retryDelay, err = time.ParseDuration(d); err != nil {
+			return nil, fmt.Errorf("env var %s %q can not be parsed as duration", envPushRetryDelay, d)
+		}
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	if to, exists := os.LookupEnv(envPushTimeout); exists {
+		if client.Timeout, err = time.ParseDuration(to); err != nil {
+			return nil, fmt.Errorf("env var %s %q can not be parsed as duration", envPushTimeout, to)
+		}
+	}
+	labels, err := parseLabels(os.Getenv(envPushLabels))
+	if err != nil {
+		return nil, err
+	}
+	job := defaultJob
+	if j, exists := os.LookupEnv(envPushJob); exists {
+		job = j
+	}
+
+	switch p.mode {
+	case ModePushgateway:
+		p.sender = newPushgateway(url, job, labels, gatherer, client)
+	case ModeRemoteWrite:
+		p.sender = newRemoteWrite(url, job, labels, gatherer, client)
+	default:
+		return nil, fmt.Errorf("env var %s contains unknown push mode %q", envPushMode, p.mode)
+	}
+	return p, nil
+}
+
+// Start pushing the metrics every interval until the context is done
+func (p *Pusher) Start(ctx context.Context) {
+	log.WithFields(log.Fields{"mode": p.mode, "url": p.url, "interval": fmt.Sprintf("%v", p.interval)}).Info("Starting metrics push")
+	go func() {
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := p.push(ctx); err != nil {
+					log.WithFields(log.Fields{"mode": p.mode, "url": p.url}).WithError(err).Error("Error pushing metrics")
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// push the metrics, retrying on errors that are not permanent
+func (p *Pusher) push(ctx context.Context) error {
+	var err error
+	for attempt := 0; attempt <= p.retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(p.retryDelay):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		if err = p.sender.send(ctx); err == nil {
+			return nil
+		}
+		if errors.As(err, &permanentError{}) {
+			return err
+		}
+		log.WithFields(log.Fields{"mode": p.mode, "attempt": attempt + 1}).WithError(err).Debug("Push failed")
+	}
+	return err
+}
+
+func parseLabels(value string) (map[string]string, error) {
+	labels := make(map[string]string)
+	if strings.TrimSpace(value) == "" {
+		return labels, nil
+	}
+	for l := range strings.SplitSeq(value, check.Separator) {
+		k, v, ok := strings.Cut(l, "=")
+		if !ok || strings.TrimSpace(k) == "" {
+			return nil, fmt.Errorf("env var %s label %q is not a key=value pair", envPushLabels, l)
+		}
+		labels[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return labels, nil
+}
@@ -0,0 +1,199 @@
+package push
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/encoding/protowire"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func Test_FromEnv(t *testing.T) {
+	p, err := FromEnv(prometheus.NewRegistry(), time.Minute)
+	assert.Assert(t, is.Nil(err))
+	assert.Assert(t, p == nil)
+
+	t.Setenv(envPushMode, ModeRemoteWrite)
+	_, err = FromEnv(prometheus.NewRegistry(), time.Minute)
+	assert.Assert(t, is.Error(err, `"PUSH_URL" must be defined to use push mode remote-write`))
+
+	t.Setenv(envPushURL, "http://localhost:9090/api/v1/write")
+	t.Setenv(envPushInterval, "15s")
+	p, err = FromEnv(prometheus.NewRegistry(), time.Minute)
+	assert.Assert(t, is.Nil(err))
+	assert.Assert(t, is.Equal(p.interval, 15*time.Second))
+
+	t.Setenv(envPushInterval, "0s")
+	_, err = FromEnv(prometheus.NewRegistry(), time.Minute)
+	assert.Assert(t, is.Error(err, `env var PUSH_INTERVAL "0s" must be a positive duration`))
+	t.Setenv(envPushInterval, "15s")
+
+	t.Setenv(envPushLabels, "cluster")
+	_, err = FromEnv(prometheus.NewRegistry(), time.Minute)
+	assert.Assert(t, is.Error(err, `env var PUSH_LABELS label "cluster" is not a key=value pair`))
+
+	t.Setenv(envPushLabels, "")
+	t.Setenv(envPushMode, "graphite")
+	_, err = FromEnv(prometheus.NewRegistry(), time.Minute)
+	assert.Assert(t, is.Error(err, `env var PUSH_MODE contains unknown push mode "graphite"`))
+}
+
+func Test_Pusher_pushgateway(t *testing.T) {
+	calls := 0
+	var path, body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		assert.Assert(t, is.Equal(r.Method, http.MethodPut))
+		path = r.URL.Path
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	p := &Pusher{
+		mode:       ModePushgateway,
+		retries:    1,
+		retryDelay: time.Millisecond,
+		sender:     newPushgateway(srv.URL, "dns_checker", map[string]string{"cluster": "prod"}, testRegistry(), srv.Client()),
+	}
+	assert.Assert(t, is.Nil(p.push(context.Background())))
+	assert.Assert(t, is.Equal(calls, 2))
+	assert.Assert(t, is.Equal(path, "/metrics/job/dns_checker/cluster/prod"))
+	assert.Assert(t, len(body) > 0)
+}
+
+func Test_Pusher_remoteWrite(t *testing.T) {
+	var series []map[string]string
+	var values []float64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Assert(t, is.Equal(r.Header.Get("Content-Encoding"), "snappy"))
+		assert.Assert(t, is.Equal(r.Header.Get("Content-Type"), "application/x-protobuf"))
+		compressed, _ := io.ReadAll(r.Body)
+		b, err := snappy.Decode(nil, compressed)
+		assert.Assert(t, is.Nil(err))
+		series, values = decodeWriteRequest(t, b)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	p := &Pusher{
+		mode:   ModeRemoteWrite,
+		sender: newRemoteWrite(srv.URL, "dns_checker", map[string]string{"cluster": "prod"}, testRegistry(), srv.Client()),
+	}
+	assert.Assert(t, is.Nil(p.push(context.Background())))
+
+	var names []string
+	for i, s := range series {
+		assert.Assert(t, is.Equal(s["job"], "dns_checker"))
+		assert.Assert(t, is.Equal(s["cluster"], "prod"))
+		assert.Assert(t, is.Equal(s["target"], "host.name"))
+		names = append(names, s["__name__"]+"{"+s["le"]+"}")
+		if s["__name__"] == "test_error" {
+			assert.Assert(t, is.Equal(values[i], 1.))
+		}
+	}
+	sort.Strings(names)
+	assert.Assert(t, is.DeepEqual(names, []string{
+		"test_error{}",
+		"test_histogram_bucket{+Inf}",
+		"test_histogram_bucket{0.5}",
+		"test_histogram_bucket{1}",
+		"test_histogram_count{}",
+		"test_histogram_sum{}",
+	}))
+}
+
+func Test_Pusher_remoteWrite_error(t *testing.T) {
+	calls := 0
+	status := http.StatusBadRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		http.Error(w, "out of order sample", status)
+	}))
+	defer srv.Close()
+
+	p := &Pusher{
+		mode:       ModeRemoteWrite,
+		retries:    2,
+		retryDelay: time.Millisecond,
+		sender:     newRemoteWrite(srv.URL, "dns_checker", nil, testRegistry(), srv.Client()),
+	}
+	err := p.push(context.Background())
+	assert.Assert(t, is.Error(err, `unexpected response status "400 Bad Request": out of order sample`))
+	// client errors are not retried
+	assert.Assert(t, is.Equal(calls, 1))
+
+	calls = 0
+	status = http.StatusTooManyRequests
+	assert.Assert(t, p.push(context.Background()) != nil)
+	assert.Assert(t, is.Equal(calls, 3))
+
+	calls = 0
+	status = http.StatusServiceUnavailable
+	assert.Assert(t, p.push(context.Background()) != nil)
+	assert.Assert(t, is.Equal(calls, 3))
+}
+
+func testRegistry() *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "test_error"}, []string{"target"})
+	histogram := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "test_histogram", Buckets: []float64{0.5, 1}}, []string{"target"})
+	registry.MustRegister(gauge, histogram)
+	gauge.WithLabelValues("host.name").Set(1)
+	histogram.WithLabelValues("host.name").Observe(0.7)
+	return registry
+}
+
+// decodeWriteRequest decode the labels and first sample value of each series of a WriteRequest
+func decodeWriteRequest(t *testing.T, b []byte) ([]map[string]string, []float64) {
+	var series []map[string]string
+	var values []float64
+	forEachField(t, b, func(_ protowire.Number, ts []byte) {
+		labels := map[string]string{}
+		var names []string
+		forEachField(t, ts, func(num protowire.Number, v []byte) {
+			if num == 1 {
+				var l []string
+				forEachField(t, v, func(_ protowire.Number, s []byte) { l = append(l, string(s)) })
+				labels[l[0]] = l[1]
+				names = append(names, l[0])
+			} else {
+				val, n := protowire.ConsumeFixed64(v[1:])
+				assert.Assert(t, n > 0)
+				values = append(values, math.Float64frombits(val))
+			}
+		})
+		assert.Assert(t, sort.StringsAreSorted(names), strings.Join(names, ","))
+		series = append(series, labels)
+	})
+	return series, values
+}
+
+// forEachField call the function for each length delimited field of the message
+func forEachField(t *testing.T, b []byte, fn func(protowire.Number, []byte)) {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		assert.Assert(t, n > 0)
+		b = b[n:]
+		assert.Assert(t, is.Equal(typ, protowire.BytesType))
+		v, n := protowire.ConsumeBytes(b)
+		assert.Assert(t, n > 0)
+		b = b[n:]
+		fn(num, v)
+	}
+}
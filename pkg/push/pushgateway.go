@@ -0,0 +1,25 @@
+package push
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+func newPushgateway(url string, job string, labels map[string]string, gatherer prometheus.Gatherer, client *http.Client) sender {
+	pusher := push.New(url, job).Gatherer(gatherer).Client(client)
+	for k, v := range labels {
+		pusher = pusher.Grouping(k, v)
+	}
+	return &pushgateway{pusher: pusher}
+}
+
+type pushgateway struct {
+	pusher *push.Pusher
+}
+
+func (p *pushgateway) send(ctx context.Context) error {
+	return p.pusher.PushContext(ctx)
+}
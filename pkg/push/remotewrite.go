@@ -0,0 +1,170 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func newRemoteWrite(url string, job string, labels map[string]string, gatherer prometheus.Gatherer, client *http.Client) sender {
+	extra := []label{{name: "job", value: job}}
+	for k, v := range labels {
+		extra = append(extra, label{name: k, value: v})
+	}
+	return &remoteWrite{url: url, labels: extra, gatherer: gatherer, client: client}
+}
+
+// remoteWrite sends the metrics via the prometheus remote-write 1.0 protocol
+type remoteWrite struct {
+	url      string
+	labels   []label
+	gatherer prometheus.Gatherer
+	client   *http.Client
+}
+
+type label struct {
+	name  string
+	value string
+}
+
+type sample struct {
+	value     float64
+	timestamp int64
+}
+
+type timeSeries struct {
+	labels  []label
+	samples []sample
+}
+
+func (r *remoteWrite) send(ctx context.Context) error {
+	families, err := r.gatherer.Gather()
+	if err != nil {
+		return err
+	}
+	body := snappy.Encode(nil, encodeWriteRequest(toTimeSeries(families, r.labels, time.Now())))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+		err := fmt.Errorf("unexpected response status %q: %s", resp.Status, strings.TrimSpace(string(msg)))
+		// only server errors and rate limits may be retried according to the remote-write spec
+		if resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+			return permanentError{err}
+		}
+		return err
+	}
+	return nil
+}
+
+// toTimeSeries convert the metric families into series; summaries and histograms are split into their classic series
+func toTimeSeries(families []*dto.MetricFamily, extra []label, now time.Time) []timeSeries {
+	ts := now.UnixMilli()
+	var series []timeSeries
+	add := func(name string, m *dto.Metric, value float64, additional ...label) {
+		labels := []label{{name: "__name__", value: name}}
+		for _, l := range m.GetLabel() {
+			labels = append(labels, label{name: l.GetName(), value: l.GetValue()})
+		}
+		labels = append(labels, additional...)
+		for _, e := range extra {
+			if !slices.ContainsFunc(labels, func(l label) bool { return l.name == e.name }) {
+				labels = append(labels, e)
+			}
+		}
+		slices.SortFunc(labels, func(a, b label) int { return strings.Compare(a.name, b.name) })
+		series = append(series, timeSeries{labels: labels, samples: []sample{{value: value, timestamp: ts}}})
+	}
+
+	for _, f := range families {
+		name := f.GetName()
+		for _, m := range f.GetMetric() {
+			switch f.GetType() {
+			case dto.MetricType_COUNTER:
+				add(name, m, m.GetCounter().GetValue())
+			case dto.MetricType_GAUGE:
+				add(name, m, m.GetGauge().GetValue())
+			case dto.MetricType_UNTYPED:
+				add(name, m, m.GetUntyped().GetValue())
+			case dto.MetricType_SUMMARY:
+				s := m.GetSummary()
+				for _, q := range s.GetQuantile() {
+					add(name, m, q.GetValue(), label{name: "quantile", value: formatFloat(q.GetQuantile())})
+				}
+				add(name+"_sum", m, s.GetSampleSum())
+				add(name+"_count", m, float64(s.GetSampleCount()))
+			case dto.MetricType_HISTOGRAM, dto.MetricType_GAUGE_HISTOGRAM:
+				h := m.GetHistogram()
+				for _, b := range h.GetBucket() {
+					add(name+"_bucket", m, float64(b.GetCumulativeCount()), label{name: "le", value: formatFloat(b.GetUpperBound())})
+				}
+				if b := h.GetBucket(); len(b) == 0 || !math.IsInf(b[len(b)-1].GetUpperBound(), 1) {
+					add(name+"_bucket", m, float64(h.GetSampleCount()), label{name: "le", value: "+Inf"})
+				}
+				add(name+"_sum", m, h.GetSampleSum())
+				add(name+"_count", m, float64(h.GetSampleCount()))
+			}
+		}
+	}
+	return series
+}
+
+// encodeWriteRequest encode the series as prometheus.WriteRequest protobuf message
+func encodeWriteRequest(series []timeSeries) []byte {
+	var req []byte
+	for _, s := range series {
+		var ts []byte
+		for _, l := range s.labels {
+			var lb []byte
+			lb = protowire.AppendTag(lb, 1, protowire.BytesType)
+			lb = protowire.AppendString(lb, l.name)
+			lb = protowire.AppendTag(lb, 2, protowire.BytesType)
+			lb = protowire.AppendString(lb, l.value)
+			ts = protowire.AppendTag(ts, 1, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, lb)
+		}
+		for _, smp := range s.samples {
+			var sb []byte
+			sb = protowire.AppendTag(sb, 1, protowire.Fixed64Type)
+			sb = protowire.AppendFixed64(sb, math.Float64bits(smp.value))
+			sb = protowire.AppendTag(sb, 2, protowire.VarintType)
+			sb = protowire.AppendVarint(sb, uint64(smp.timestamp))
+			ts = protowire.AppendTag(ts, 2, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, sb)
+		}
+		req = protowire.AppendTag(req, 1, protowire.BytesType)
+		req = protowire.AppendBytes(req, ts)
+	}
+	return req
+}
+
+func formatFloat(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+	return fmt.Sprintf("%v", f)
+}
@@ -0,0 +1,33 @@
+package run
+
+import (
+	"context"
+	"time"
+
+	"github.com/bakito/dns-checker/pkg/controller"
+)
+
+// controllerResolution the resolution the intervals of the dnscheck resources are scheduled with
+const controllerResolution = time.Second
+
+// scheduleResources dispatch the due checks of the dnscheck resources to the workers
+func scheduleResources(ctx context.Context, ctrl *controller.Controller, collector collector, execChan chan execution) {
+	ticker := time.NewTicker(controllerResolution)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			for _, j := range ctrl.Due(now) {
+				for _, chk := range j.Checks {
+					select {
+					case collector.work <- work{ctx, j.Interval, execChan, j.Address, chk, nil, j.Resource}:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/bakito/dns-checker/pkg/check"
@@ -15,13 +16,27 @@ import (
 	"github.com/bakito/dns-checker/pkg/check/manualdns"
 	"github.com/bakito/dns-checker/pkg/check/port"
 	"github.com/bakito/dns-checker/pkg/check/shell"
+	"github.com/bakito/dns-checker/pkg/controller"
+	"github.com/bakito/dns-checker/pkg/discovery/srv"
+	"github.com/bakito/dns-checker/pkg/events"
+	"github.com/bakito/dns-checker/pkg/health"
+	"github.com/bakito/dns-checker/pkg/notify"
+	"github.com/bakito/dns-checker/pkg/sink/influxdb"
+	"github.com/bakito/dns-checker/pkg/sink/statsd"
+	"github.com/bakito/dns-checker/pkg/status"
 	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const (
 	envManualDNSHost = "MANUAL_DNS_HOST"
 	envEnabledChecks = "ENABLED_CHECKS"
 	envLogDuration   = "LOG_DURATION"
+	envMetricsSinks  = "METRICS_SINKS"
+	// envStaleIntervals the number of intervals without results after which the series of a target check are deleted; 0 disables the cleanup
+	envStaleIntervals = "METRICS_STALE_INTERVALS"
+
+	defaultStaleIntervals = 5
 )
 
 var (
@@ -34,37 +49,151 @@ func Check(values []string, interval time.Duration, timeout time.Duration, worke
 	if err != nil {
 		return err
 	}
+	discoverers, err := discoverers(values)
+	if err != nil {
+		return err
+	}
+	if err := checkResolverTargets(targetsAddresses, discoverers); err != nil {
+		return err
+	}
+	targets := newTargetSet(targetsAddresses, discoverers...)
 
 	checks, err := checks()
 	if err != nil {
 		return err
 	}
 
+	sinks, err := sinks()
+	if err != nil {
+		return err
+	}
+	check.SetSinks(sinks...)
+
+	failureThreshold, err := intEnv(envFailureThreshold, 1)
+	if err != nil {
+		return err
+	}
+	recoveryThreshold, err := intEnv(envRecoveryThreshold, 1)
+	if err != nil {
+		return err
+	}
+	states, err := newStateTracker(failureThreshold, recoveryThreshold)
+	if err != nil {
+		return err
+	}
+	flapWindow, err := intEnv(envFlapWindow, defaultFlapWindow)
+	if err != nil {
+		return err
+	}
+	flapLow, err := floatEnv(envFlapLowThreshold, defaultFlapLowThreshold)
+	if err != nil {
+		return err
+	}
+	flapHigh, err := floatEnv(envFlapHighThreshold, defaultFlapHighThreshold)
+	if err != nil {
+		return err
+	}
+	states.flap, err = newFlapDetection(flapWindow, flapLow, flapHigh)
+	if err != nil {
+		return err
+	}
+	staleIntervals, err := intEnv(envStaleIntervals, defaultStaleIntervals)
+	if err != nil {
+		return err
+	}
+
+	handler := &resultHandler{states: states}
+	if controller.Enabled() {
+		if handler.controller, err = controller.New(checks, interval); err != nil {
+			return err
+		}
+	}
+	webhook, err := notify.NewWebhookFromEnv()
+	if err != nil {
+		return err
+	}
+	if webhook != nil {
+		handler.notifiers = append(handler.notifiers, webhook)
+	}
+
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt)
 	ctx, cancel := context.WithCancel(context.Background())
 
+	for _, n := range handler.notifiers {
+		n.Start(ctx)
+	}
+	for _, s := range sinks {
+		if bs, ok := s.(check.BackgroundSink); ok {
+			bs.Start(ctx)
+		}
+	}
+	if err := targets.start(ctx); err != nil {
+		cancel()
+		return err
+	}
+	if handler.controller != nil {
+		if err := handler.controller.Start(ctx); err != nil {
+			cancel()
+			return err
+		}
+	}
+
 	execChan := make(chan execution)
-	go handleResults(ctx, execChan)
+	go handleResults(ctx, execChan, handler)
 
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 	check.Init(timeout)
+	check.ReportTargets(len(targets.list()))
 
 	collector := startDispatcher(worker) // start up worker pool
+	health.Start(interval)
+	activeTrigger.Store(newTrigger(ctx, interval, collector, execChan, targets, checks))
+	if handler.controller != nil {
+		go scheduleResources(ctx, handler.controller, collector, execChan)
+	}
 
+	var firstRoundScheduled bool
 	for {
 		select {
 		case <-ticker.C:
 
-			for _, t := range targetsAddresses {
-				for chk := range checks {
-					collector.work <- work{ctx, interval, execChan, t, checks[chk]}
+			current := targets.list()
+			currentChecks := targets.checks(checks)
+			check.ReportTargets(len(current))
+
+			var round *sync.WaitGroup
+			if !firstRoundScheduled {
+				round = firstRound(len(current) * len(currentChecks))
+				firstRoundScheduled = true
+			}
+
+			for _, t := range current {
+				for chk := range currentChecks {
+					collector.work <- work{ctx, interval, execChan, t, currentChecks[chk], round, ""}
+				}
+			}
+			health.Scheduled(len(current) * len(currentChecks))
+
+			if staleIntervals > 0 {
+				stale := check.DeleteStaleChecks(time.Duration(staleIntervals) * interval)
+				for _, sc := range stale {
+					states.remove(sc.Name, sc.Address)
+					status.Delete(sc.Name, sc.Address)
+				}
+				if len(stale) > 0 {
+					log.WithField("series", len(stale)).Debug("Deleted stale metric series")
 				}
 			}
 
 		case <-sigChan:
 			cancel()
+			for _, s := range sinks {
+				if bs, ok := s.(check.BackgroundSink); ok {
+					bs.Wait()
+				}
+			}
 			return nil
 		}
 	}
@@ -75,6 +204,10 @@ func toTargets(values []string) ([]check.Address, error) {
 	for _, value := range values {
 		targets := strings.SplitSeq(value, ",")
 		for t := range targets {
+			if strings.HasPrefix(strings.TrimSpace(t), srv.Prefix) {
+				// srv records are resolved by the srv discovery
+				continue
+			}
 			target, err := toTarget(t)
 			if err != nil {
 				return nil, err
@@ -92,11 +225,23 @@ func toTargets(values []string) ([]check.Address, error) {
 	return targetsAddresses, nil
 }
 
-func handleResults(ctx context.Context, ex chan execution) {
+// firstRound mark the checker as ready when all checks of the first round are completed
+func firstRound(size int) *sync.WaitGroup {
+	round := &sync.WaitGroup{}
+	round.Add(size)
+	go func() {
+		round.Wait()
+		health.Ready()
+	}()
+	return round
+}
+
+func handleResults(ctx context.Context, ex chan execution, handler *resultHandler) {
 	for {
 		select {
 		case e := <-ex:
-			e.check.Report(e.Address, e.Result)
+			handler.handle(e)
+			health.Processed()
 
 		case <-ctx.Done():
 			return
@@ -104,29 +249,98 @@ func handleResults(ctx context.Context, ex chan execution) {
 	}
 }
 
+type resultHandler struct {
+	states     *stateTracker
+	notifiers  []notify.Notifier
+	controller *controller.Controller
+}
+
+func (h *resultHandler) handle(e execution) {
+	if e.skipped {
+		if h.controller != nil {
+			h.controller.Skip(e.resource)
+		}
+		return
+	}
+	e.check.Report(e.Address, e.Result)
+	tr := h.states.update(e)
+	check.ReportState(e.Address, e.check.Name(), tr.previous, tr.current)
+	check.ReportFlapping(e.Address, e.check.Name(), tr.flapping)
+	status.Update(e.check.Name(), e.Address, e.Result, tr.current, tr.flapping)
+	for _, a := range status.Availabilities(e.check.Name(), e.Address) {
+		check.ReportAvailability(e.Address, e.check.Name(), a.Window, a.Availability, a.BurnRate)
+	}
+	events.Publish(events.New(e.check.Name(), e.Address, e.Result, tr.current))
+	if h.controller != nil && e.resource != "" {
+		h.controller.Record(e.resource, e.check.Name(), e.Result, tr.current)
+	}
+	if tr.flappingChanged {
+		logFlapping(e, tr)
+	}
+	if tr.logged() {
+		logTransition(e, tr)
+	}
+	if tr.notify() {
+		n := notify.New(e.check.Name(), e.Address, e.Result, tr.previous, tr.current)
+		for _, notifier := range h.notifiers {
+			notifier.Notify(n)
+		}
+	}
+}
+
 func runCheck(w work, workerID int) {
+	if w.round != nil {
+		defer w.round.Done()
+	}
 	ctx, cancel := context.WithTimeout(w.ctx, w.interval)
 	defer cancel()
 
+	ctx, span := check.StartSpan(ctx, "check "+w.chk.Name(), spanAttributes(w, workerID)...)
 	start := time.Now()
 	result := w.chk.Run(ctx, w.target)
 	duration := time.Since(start)
+	if result != nil {
+		check.EndSpan(span, result.Err)
+	} else {
+		span.End()
+	}
 
 	if log.GetLevel() > log.InfoLevel || boolEnv(envLogDuration) {
 		logDuration(w.chk, workerID, w.target, result, duration)
 	}
-	if result != nil {
-		result.WorkerID = workerID
-		ex := newExecution(w.chk, w.target)
-		if result.Duration == nil {
-			ex.Duration = &duration
-		} else {
-			ex.Duration = result.Duration
+	if result == nil {
+		if w.resource != "" {
+			// the controller waits for all checks of the resource
+			ex := newExecution(w.chk, w.target)
+			ex.resource = w.resource
+			ex.skipped = true
+			w.resultsChan <- ex
 		}
-		ex.Err = result.Err
-		ex.TimedOut = result.Err == context.Canceled
-		w.resultsChan <- ex
+		return
+	}
+	ex := newExecution(w.chk, w.target)
+	if result.Duration == nil {
+		ex.Duration = &duration
+	} else {
+		ex.Duration = result.Duration
+	}
+	ex.Err = result.Err
+	ex.TimedOut = result.Err == context.Canceled
+	ex.WorkerID = workerID
+	ex.resource = w.resource
+	w.resultsChan <- ex
+}
+
+func spanAttributes(w work, workerID int) []attribute.KeyValue {
+	attributes := []attribute.KeyValue{
+		attribute.String("target", w.target.Host),
+		attribute.String("check_name", w.chk.Name()),
+		attribute.Int("worker_id", workerID),
+	}
+	if w.target.Port != nil {
+		attributes = append(attributes, attribute.Int("port", *w.target.Port))
 	}
+	return attributes
 }
 
 func logDuration(chk check.Check, workerID int, target check.Address, result *check.Result, duration time.Duration) {
@@ -180,6 +394,28 @@ func boolEnv(name string) bool {
 	return false
 }
 
+func intEnv(name string, def int) (int, error) {
+	if val, exists := os.LookupEnv(name); exists {
+		i, err := strconv.Atoi(val)
+		if err != nil {
+			return 0, fmt.Errorf("env var %s %q can not be parsed as int", name, val)
+		}
+		return i, nil
+	}
+	return def, nil
+}
+
+func floatEnv(name string, def float64) (float64, error) {
+	if val, exists := os.LookupEnv(name); exists {
+		f, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return 0, fmt.Errorf("env var %s %q can not be parsed as float", name, val)
+		}
+		return f, nil
+	}
+	return def, nil
+}
+
 func checks() ([]check.Check, error) {
 	if checks, exists := os.LookupEnv(envEnabledChecks); exists {
 		names := make(map[string]bool)
@@ -210,3 +446,31 @@ func checks() ([]check.Check, error) {
 	}
 	return []check.Check{dns.New(), port.New()}, nil
 }
+
+func sinks() ([]check.Sink, error) {
+	if value, exists := os.LookupEnv(envMetricsSinks); exists {
+		var enabled []check.Sink
+		for n := range strings.SplitSeq(value, check.Separator) {
+			switch strings.TrimSpace(n) {
+			case check.SinkPrometheus:
+				enabled = append(enabled, check.PrometheusSink())
+			case statsd.Name:
+				s, err := statsd.New()
+				if err != nil {
+					return nil, err
+				}
+				enabled = append(enabled, s)
+			case influxdb.Name:
+				s, err := influxdb.New()
+				if err != nil {
+					return nil, err
+				}
+				enabled = append(enabled, s)
+			default:
+				return nil, fmt.Errorf("env var %s contains unknown sink %q", envMetricsSinks, n)
+			}
+		}
+		return enabled, nil
+	}
+	return []check.Sink{check.PrometheusSink()}, nil
+}
@@ -1,8 +1,17 @@
 package run
 
 import (
+	"context"
+	"errors"
 	"os"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/bakito/dns-checker/pkg/check"
+	"github.com/bakito/dns-checker/pkg/check/dns"
+	"github.com/bakito/dns-checker/pkg/check/port"
+	"github.com/bakito/dns-checker/pkg/notify"
 
 	"gotest.tools/assert"
 	is "gotest.tools/assert/cmp"
@@ -13,7 +22,7 @@ const (
 )
 
 func Test_toTargets(t *testing.T) {
-	targets, err := toTargets([]string{"a", "b:1234", "c,d:5678 , e:9999     "})
+	targets, err := toTargets([]string{"a", "b:1234", "c,d:5678 , e:9999     ", "srv+_http._tcp.example.com"})
 	assert.Assert(t, is.Nil(err))
 	assert.Assert(t, is.Len(targets, 5))
 }
@@ -40,3 +49,71 @@ func Test_fromEnv(t *testing.T) {
 	assert.Assert(t, is.Equal(fromEnv("bar"), "bar"))
 	assert.Assert(t, is.Equal(fromEnv("${"+testEnv+"}"), "foo"))
 }
+
+func Test_resultHandler_notify(t *testing.T) {
+	initMetrics()
+	st, err := newStateTracker(2, 1)
+	assert.Assert(t, is.Nil(err))
+	n := &testNotifier{}
+	h := &resultHandler{states: st, notifiers: []notify.Notifier{n}}
+
+	ok := newExecution(dns.New(), check.Address{Host: "host.name"})
+	ok.Duration = new(time.Millisecond)
+	nok := ok
+	nok.Err = errors.New("failed")
+
+	for _, e := range []execution{ok, nok, nok, nok, ok} {
+		h.handle(e)
+	}
+
+	assert.Assert(t, is.Len(n.notifications, 2))
+	assert.Assert(t, is.Equal(n.notifications[0].State, check.StateDown))
+	assert.Assert(t, is.Equal(n.notifications[0].Error, "failed"))
+	assert.Assert(t, is.Equal(n.notifications[1].PreviousState, check.StateDown))
+	assert.Assert(t, is.Equal(n.notifications[1].State, check.StateUp))
+}
+
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		check.Init(time.Second)
+	})
+}
+
+type testNotifier struct {
+	notifications []notify.Notification
+}
+
+func (n *testNotifier) Start(_ context.Context) {}
+
+func (n *testNotifier) Notify(notification notify.Notification) {
+	n.notifications = append(n.notifications, notification)
+}
+
+func Test_sinks(t *testing.T) {
+	s, err := sinks()
+	assert.Assert(t, is.Nil(err))
+	assert.Assert(t, is.Len(s, 1))
+
+	t.Setenv(envMetricsSinks, "prometheus,statsd")
+	s, err = sinks()
+	assert.Assert(t, is.Nil(err))
+	assert.Assert(t, is.Len(s, 2))
+
+	t.Setenv(envMetricsSinks, "graphite")
+	_, err = sinks()
+	assert.Assert(t, is.Error(err, `env var METRICS_SINKS contains unknown sink "graphite"`))
+}
+
+func Test_runCheck_skipped(t *testing.T) {
+	resultsChan := make(chan execution, 1)
+	runCheck(work{context.Background(), time.Second, resultsChan, check.Address{Host: "host.name"}, port.New(), nil, "app/web"}, 1)
+	e := <-resultsChan
+	assert.Assert(t, e.skipped)
+	assert.Assert(t, is.Equal(e.resource, "app/web"))
+
+	// skipped checks of configured targets are not reported
+	runCheck(work{context.Background(), time.Second, resultsChan, check.Address{Host: "host.name"}, port.New(), nil, ""}, 1)
+	assert.Assert(t, is.Len(resultsChan, 0))
+}
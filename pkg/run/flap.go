@@ -0,0 +1,70 @@
+package run
+
+import (
+	"fmt"
+)
+
+const (
+	envFlapWindow        = "FLAP_WINDOW"
+	envFlapLowThreshold  = "FLAP_LOW_THRESHOLD"
+	envFlapHighThreshold = "FLAP_HIGH_THRESHOLD"
+
+	defaultFlapWindow        = 21
+	defaultFlapLowThreshold  = 25.
+	defaultFlapHighThreshold = 50.
+)
+
+// newFlapDetection create a new flap detection. A window of 0 disables the detection.
+func newFlapDetection(window int, low float64, high float64) (*flapDetection, error) {
+	if window == 0 {
+		return nil, nil
+	}
+	if window < 3 {
+		return nil, fmt.Errorf("%s must be 0 or at least 3 but was %d", envFlapWindow, window)
+	}
+	if low < 0 || high > 100 || low > high {
+		return nil, fmt.Errorf("flap thresholds must be 0 <= %s (%v) <= %s (%v) <= 100",
+			envFlapLowThreshold, low, envFlapHighThreshold, high)
+	}
+	return &flapDetection{window: window, low: low, high: high}, nil
+}
+
+// flapDetection detects flapping the same way nagios does.
+// The percent state change over a sliding window of results is calculated, weighting recent changes higher than older ones.
+// A check starts flapping when the change exceeds the high threshold and stops when it falls below the low threshold.
+type flapDetection struct {
+	window int
+	low    float64
+	high   float64
+}
+
+// record the result in the history and return whether the check is flapping
+func (f *flapDetection) record(ts *targetState, success bool) bool {
+	ts.history = append(ts.history, success)
+	if len(ts.history) > f.window {
+		ts.history = ts.history[len(ts.history)-f.window:]
+	}
+
+	change := percentStateChange(ts.history)
+	if ts.flapping {
+		ts.flapping = change >= f.low
+	} else {
+		ts.flapping = change > f.high
+	}
+	return ts.flapping
+}
+
+// percentStateChange the weighted percent of state changes in the history; oldest changes weight 0.8, newest 1.2
+func percentStateChange(history []bool) float64 {
+	if len(history) < 3 {
+		return 0
+	}
+	var change float64
+	changes := len(history) - 1
+	for i := 1; i < len(history); i++ {
+		if history[i] != history[i-1] {
+			change += 0.8 + 0.4*float64(i-1)/float64(changes-1)
+		}
+	}
+	return change * 100 / float64(changes)
+}
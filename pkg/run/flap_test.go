@@ -0,0 +1,64 @@
+package run
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/bakito/dns-checker/pkg/check"
+	"github.com/bakito/dns-checker/pkg/check/dns"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func Test_newFlapDetection(t *testing.T) {
+	f, err := newFlapDetection(0, 25, 50)
+	assert.Assert(t, is.Nil(err))
+	assert.Assert(t, f == nil)
+
+	_, err = newFlapDetection(2, 25, 50)
+	assert.Assert(t, is.Error(err, "FLAP_WINDOW must be 0 or at least 3 but was 2"))
+
+	_, err = newFlapDetection(21, 60, 50)
+	assert.Assert(t, is.ErrorContains(err, "flap thresholds must be"))
+}
+
+func Test_percentStateChange(t *testing.T) {
+	assert.Assert(t, is.Equal(percentStateChange([]bool{true, false}), 0.))
+	assert.Assert(t, is.Equal(percentStateChange([]bool{true, true, true, true}), 0.))
+	assert.Assert(t, is.Equal(percentStateChange([]bool{true, false, true, false, true}), 100.))
+	// only the newest change weighted with 1.2
+	assert.Assert(t, is.Equal(math.Round(percentStateChange([]bool{true, true, true, true, false})), 30.))
+}
+
+func Test_stateTracker_flapping(t *testing.T) {
+	st, err := newStateTracker(1, 1)
+	assert.Assert(t, is.Nil(err))
+	st.flap, err = newFlapDetection(5, 25, 50)
+	assert.Assert(t, is.Nil(err))
+
+	ok := newExecution(dns.New(), check.Address{Host: "host.name"})
+	nok := ok
+	nok.Err = errors.New("failed")
+
+	for _, e := range []execution{ok, ok, ok, nok} {
+		tr := st.update(e)
+		assert.Assert(t, !tr.flapping)
+	}
+
+	tr := st.update(ok)
+	assert.Assert(t, tr.flapping)
+	assert.Assert(t, tr.flappingChanged)
+	assert.Assert(t, tr.changed())
+	assert.Assert(t, !tr.notify())
+
+	tr = st.update(nok)
+	assert.Assert(t, tr.flapping)
+	assert.Assert(t, !tr.flappingChanged)
+
+	for range 3 {
+		tr = st.update(nok)
+	}
+	assert.Assert(t, !tr.flapping)
+	assert.Assert(t, tr.flappingChanged)
+}
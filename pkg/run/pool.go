@@ -2,6 +2,7 @@ package run
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/bakito/dns-checker/pkg/check"
@@ -59,6 +60,8 @@ type work struct {
 	resultsChan chan execution
 	target      check.Address
 	chk         check.Check
+	round       *sync.WaitGroup // the round of the work, if completion is tracked
+	resource    string          // the dnscheck resource of the work, if the result is written to its status
 }
 
 type worker struct {
@@ -0,0 +1,149 @@
+package run
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/bakito/dns-checker/pkg/check"
+	"github.com/bakito/dns-checker/pkg/status"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	envFailureThreshold  = "FAILURE_THRESHOLD"
+	envRecoveryThreshold = "RECOVERY_THRESHOLD"
+)
+
+func newStateTracker(failureThreshold int, recoveryThreshold int) (*stateTracker, error) {
+	if failureThreshold < 1 {
+		return nil, fmt.Errorf("%s must be at least 1 but was %d", envFailureThreshold, failureThreshold)
+	}
+	if recoveryThreshold < 1 {
+		return nil, fmt.Errorf("%s must be at least 1 but was %d", envRecoveryThreshold, recoveryThreshold)
+	}
+	return &stateTracker{
+		failureThreshold:  failureThreshold,
+		recoveryThreshold: recoveryThreshold,
+		states:            make(map[string]*targetState),
+	}, nil
+}
+
+// stateTracker tracks the state of each target / check combination.
+// A target becomes down after failureThreshold consecutive failures and up after recoveryThreshold consecutive successes.
+type stateTracker struct {
+	failureThreshold  int
+	recoveryThreshold int
+	flap              *flapDetection
+
+	mux    sync.Mutex
+	states map[string]*targetState
+}
+
+type targetState struct {
+	state     check.State
+	failures  int
+	successes int
+	history   []bool
+	flapping  bool
+}
+
+type transition struct {
+	previous check.State
+	current  check.State
+	flapping bool
+	// flappingChanged the check started or stopped flapping
+	flappingChanged bool
+}
+
+func (t transition) changed() bool {
+	return t.previous != t.current
+}
+
+// logged the transition should be logged; transitions are suppressed while the check is flapping
+func (t transition) logged() bool {
+	return t.changed() && !t.flapping
+}
+
+// notify the transition should be notified; the initial transition from unknown to up is only logged,
+// to not notify every check on startup
+func (t transition) notify() bool {
+	if t.previous == check.StateUnknown && t.current == check.StateUp {
+		return false
+	}
+	return t.logged()
+}
+
+// update the state with the result of an execution and return the resulting transition
+func (t *stateTracker) update(e execution) transition {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	key := status.Key(e.check.Name(), e.Address)
+	ts, ok := t.states[key]
+	if !ok {
+		ts = &targetState{state: check.StateUnknown}
+		t.states[key] = ts
+	}
+
+	tr := transition{previous: ts.state, current: ts.state, flapping: ts.flapping}
+	if e.Err != nil {
+		ts.successes = 0
+		ts.failures++
+		if ts.failures >= t.failureThreshold {
+			tr.current = check.StateDown
+		}
+	} else {
+		ts.failures = 0
+		ts.successes++
+		if ts.successes >= t.recoveryThreshold {
+			tr.current = check.StateUp
+		}
+	}
+	ts.state = tr.current
+
+	if t.flap != nil {
+		wasFlapping := ts.flapping
+		tr.flapping = t.flap.record(ts, e.Err == nil)
+		tr.flappingChanged = tr.flapping != wasFlapping
+	}
+	return tr
+}
+
+// remove the state of a check
+func (t *stateTracker) remove(name string, address check.Address) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	delete(t.states, status.Key(name, address))
+}
+
+func logTransition(e execution, tr transition) {
+	l := log.WithFields(log.Fields{
+		"name":   e.check.Name(),
+		"target": e.Host,
+		"from":   tr.previous,
+		"to":     tr.current,
+	}).WithFields(check.LogFields(e.check.Name(), e.Address))
+	if e.Port != nil {
+		l = l.WithField("port", *e.Port)
+	}
+	if tr.current == check.StateDown {
+		l.Warnf("State changed : %v", e.Err)
+	} else {
+		l.Info("State changed")
+	}
+}
+
+func logFlapping(e execution, tr transition) {
+	l := log.WithFields(log.Fields{
+		"name":   e.check.Name(),
+		"target": e.Host,
+		"state":  tr.current,
+	}).WithFields(check.LogFields(e.check.Name(), e.Address))
+	if e.Port != nil {
+		l = l.WithField("port", *e.Port)
+	}
+	if tr.flapping {
+		l.Warn("Flapping started")
+	} else {
+		l.Info("Flapping stopped")
+	}
+}
@@ -0,0 +1,82 @@
+package run
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bakito/dns-checker/pkg/check"
+	"github.com/bakito/dns-checker/pkg/check/dns"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func Test_newStateTracker(t *testing.T) {
+	_, err := newStateTracker(0, 1)
+	assert.Assert(t, is.Error(err, "FAILURE_THRESHOLD must be at least 1 but was 0"))
+	_, err = newStateTracker(1, 0)
+	assert.Assert(t, is.Error(err, "RECOVERY_THRESHOLD must be at least 1 but was 0"))
+}
+
+func Test_stateTracker_update(t *testing.T) {
+	st, err := newStateTracker(3, 2)
+	assert.Assert(t, is.Nil(err))
+
+	ok := newExecution(dns.New(), check.Address{Host: "host.name"})
+	nok := ok
+	nok.Err = errors.New("failed")
+
+	steps := []struct {
+		ex       execution
+		expected check.State
+		changed  bool
+	}{
+		{nok, check.StateUnknown, false},
+		{nok, check.StateUnknown, false},
+		{nok, check.StateDown, true},
+		{nok, check.StateDown, false},
+		{ok, check.StateDown, false},
+		{nok, check.StateDown, false},
+		{ok, check.StateDown, false},
+		{ok, check.StateUp, true},
+		{nok, check.StateUp, false},
+		{ok, check.StateUp, false},
+	}
+
+	for i, s := range steps {
+		tr := st.update(s.ex)
+		assert.Assert(t, is.Equal(tr.current, s.expected), "step %d", i)
+		assert.Assert(t, is.Equal(tr.changed(), s.changed), "step %d", i)
+	}
+}
+
+func Test_stateTracker_remove(t *testing.T) {
+	st, err := newStateTracker(1, 1)
+	assert.Assert(t, is.Nil(err))
+	ok := newExecution(dns.New(), check.Address{Host: "host.name"})
+	assert.Assert(t, is.Equal(st.update(ok).current, check.StateUp))
+
+	st.remove(dns.Name, check.Address{Host: "host.name"})
+	assert.Assert(t, is.Len(st.states, 0))
+	assert.Assert(t, is.Equal(st.update(ok).previous, check.StateUnknown))
+}
+
+func Test_transition_notify(t *testing.T) {
+	assert.Assert(t, !transition{previous: check.StateUnknown, current: check.StateUp}.notify())
+	assert.Assert(t, transition{previous: check.StateUnknown, current: check.StateUp}.logged())
+	assert.Assert(t, transition{previous: check.StateUnknown, current: check.StateDown}.notify())
+	assert.Assert(t, transition{previous: check.StateDown, current: check.StateUp}.notify())
+	assert.Assert(t, !transition{previous: check.StateDown, current: check.StateUp, flapping: true}.notify())
+	assert.Assert(t, !transition{previous: check.StateDown, current: check.StateUp, flapping: true}.logged())
+	assert.Assert(t, !transition{previous: check.StateUp, current: check.StateUp}.notify())
+}
+
+func Test_intEnv(t *testing.T) {
+	t.Setenv(testEnv, "3")
+	i, err := intEnv(testEnv, 1)
+	assert.Assert(t, is.Nil(err))
+	assert.Assert(t, is.Equal(i, 3))
+
+	t.Setenv(testEnv, "x")
+	_, err = intEnv(testEnv, 1)
+	assert.Assert(t, is.Error(err, `env var ___TEST___ "x" can not be parsed as int`))
+}
@@ -0,0 +1,140 @@
+package run
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/bakito/dns-checker/pkg/check"
+	"github.com/bakito/dns-checker/pkg/discovery"
+	"github.com/bakito/dns-checker/pkg/discovery/file"
+	"github.com/bakito/dns-checker/pkg/discovery/kubernetes"
+	"github.com/bakito/dns-checker/pkg/discovery/resolvconf"
+	"github.com/bakito/dns-checker/pkg/discovery/srv"
+)
+
+// envDiscovery a ',' separated list of target discoveries
+const envDiscovery = "DISCOVERY"
+
+// targetSet the static targets and the targets of the discoveries
+type targetSet struct {
+	static      []check.Address
+	discoverers []discovery.Discoverer
+}
+
+func newTargetSet(static []check.Address, discoverers ...discovery.Discoverer) *targetSet {
+	return &targetSet{static: static, discoverers: discoverers}
+}
+
+// start all discoveries and wait for their initial targets
+func (t *targetSet) start(ctx context.Context) error {
+	for _, d := range t.discoverers {
+		if err := d.Start(ctx); err != nil {
+			return fmt.Errorf("failed to start %s discovery: %w", d.Name(), err)
+		}
+	}
+	return nil
+}
+
+// list the current targets; static and discovered targets are merged
+func (t *targetSet) list() []check.Address {
+	if len(t.discoverers) == 0 {
+		return t.static
+	}
+	targets := slices.Clone(t.static)
+	for _, d := range t.discoverers {
+		targets = append(targets, d.Targets()...)
+	}
+	return discovery.Sort(targets)
+}
+
+// checks the static checks and the checks of the discoveries providing checks
+func (t *targetSet) checks(static []check.Check) []check.Check {
+	checks := static
+	for _, d := range t.discoverers {
+		if cd, ok := d.(discovery.CheckDiscoverer); ok {
+			checks = append(slices.Clip(checks), cd.Checks()...)
+		}
+	}
+	return checks
+}
+
+// contains the address is a current target
+func (t *targetSet) contains(address check.Address) bool {
+	return slices.ContainsFunc(t.list(), func(a check.Address) bool {
+		return a.Host == address.Host && ((a.Port == nil && address.Port == nil) ||
+			(a.Port != nil && address.Port != nil && *a.Port == *address.Port))
+	})
+}
+
+// DiscoveryEnabled at least one target discovery is configured
+func DiscoveryEnabled() bool {
+	return strings.TrimSpace(os.Getenv(envDiscovery)) != ""
+}
+
+// checkResolverTargets the nameserver checks of the resolvconf discovery need static or discovered targets to resolve
+func checkResolverTargets(static []check.Address, discoverers []discovery.Discoverer) error {
+	if len(static) > 0 {
+		return nil
+	}
+	var resolvers bool
+	for _, d := range discoverers {
+		if resolvconf.ProvidesTargets(d) {
+			return nil
+		}
+		resolvers = true
+	}
+	if resolvers {
+		return fmt.Errorf("the %s discovery needs targets to resolve; set TARGET or HOSTS_FILE_TARGETS=true", resolvconf.Name)
+	}
+	return nil
+}
+
+// discoverers the discoveries configured by env variables and the srv records of the target values
+func discoverers(values []string) ([]discovery.Discoverer, error) {
+	var enabled []discovery.Discoverer
+	for _, value := range values {
+		for t := range strings.SplitSeq(value, check.Separator) {
+			if t = strings.TrimSpace(t); strings.HasPrefix(t, srv.Prefix) {
+				d, err := srv.New(fromEnv(strings.TrimPrefix(t, srv.Prefix)))
+				if err != nil {
+					return nil, err
+				}
+				enabled = append(enabled, d)
+			}
+		}
+	}
+	if len(enabled) > 0 {
+		check.RegisterTargetLabels(srv.LabelNames...)
+	}
+	if value, exists := os.LookupEnv(envDiscovery); exists {
+		for n := range strings.SplitSeq(value, check.Separator) {
+			switch strings.TrimSpace(n) {
+			case "":
+			case file.Name:
+				d, err := file.New()
+				if err != nil {
+					return nil, err
+				}
+				enabled = append(enabled, d)
+			case kubernetes.Name:
+				d, err := kubernetes.New()
+				if err != nil {
+					return nil, err
+				}
+				enabled = append(enabled, d)
+			case resolvconf.Name:
+				d, err := resolvconf.New()
+				if err != nil {
+					return nil, err
+				}
+				enabled = append(enabled, d)
+			default:
+				return nil, fmt.Errorf("env var %s contains unknown discovery %q", envDiscovery, n)
+			}
+		}
+	}
+	return enabled, nil
+}
@@ -0,0 +1,96 @@
+package run
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bakito/dns-checker/pkg/check"
+	"github.com/bakito/dns-checker/pkg/check/manualdns"
+	"github.com/bakito/dns-checker/pkg/discovery"
+	"github.com/bakito/dns-checker/pkg/discovery/resolvconf"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func Test_targetSet(t *testing.T) {
+	p80 := 80
+	p443 := 443
+	d := &testDiscoverer{targets: []check.Address{{Host: "b", Port: &p443}, {Host: "a", Port: &p80}}}
+	ts := newTargetSet([]check.Address{{Host: "a", Port: &p80}, {Host: "c"}}, d)
+
+	assert.Assert(t, is.Nil(ts.start(context.Background())))
+	assert.Assert(t, d.started)
+	assert.Assert(t, is.DeepEqual(ts.list(), []check.Address{{Host: "a", Port: &p80}, {Host: "b", Port: &p443}, {Host: "c"}}))
+	assert.Assert(t, ts.contains(check.Address{Host: "b", Port: &p443}))
+	assert.Assert(t, !ts.contains(check.Address{Host: "b"}))
+
+	d.targets = nil
+	assert.Assert(t, !ts.contains(check.Address{Host: "b", Port: &p443}))
+}
+
+func Test_targetSet_checks(t *testing.T) {
+	static := []check.Check{manualdns.New("a:53")}
+	assert.Assert(t, is.Len(newTargetSet(nil, &testDiscoverer{}).checks(static), 1))
+
+	d := &testCheckDiscoverer{checks: []check.Check{manualdns.New("b:53")}}
+	checks := newTargetSet(nil, &testDiscoverer{}, d).checks(static)
+	assert.Assert(t, is.Len(checks, 2))
+	assert.Assert(t, checks[1] == d.checks[0])
+	assert.Assert(t, is.Len(static, 1))
+}
+
+func Test_discoverers(t *testing.T) {
+	d, err := discoverers([]string{"a:80"})
+	assert.Assert(t, is.Nil(err))
+	assert.Assert(t, is.Len(d, 0))
+	assert.Assert(t, !DiscoveryEnabled())
+
+	d, err = discoverers([]string{"a:80, srv+_http._tcp.example.com", "srv+_dns._udp.example.com"})
+	assert.Assert(t, is.Nil(err))
+	assert.Assert(t, is.Len(d, 2))
+	assert.Assert(t, is.Equal(d[0].Name(), "srv"))
+
+	t.Setenv(envDiscovery, "consul")
+	assert.Assert(t, DiscoveryEnabled())
+	_, err = discoverers(nil)
+	assert.Assert(t, is.Error(err, `env var DISCOVERY contains unknown discovery "consul"`))
+}
+
+func Test_checkResolverTargets(t *testing.T) {
+	t.Setenv("RESOLV_CONF_PATH", "/nonexistent/resolv.conf")
+	resolver, err := resolvconf.New()
+	assert.Assert(t, is.Nil(err))
+
+	assert.Assert(t, is.Nil(checkResolverTargets(nil, nil)))
+	assert.Assert(t, is.Error(checkResolverTargets(nil, []discovery.Discoverer{resolver}),
+		"the resolvconf discovery needs targets to resolve; set TARGET or HOSTS_FILE_TARGETS=true"))
+	assert.Assert(t, is.Nil(checkResolverTargets([]check.Address{{Host: "a"}}, []discovery.Discoverer{resolver})))
+	assert.Assert(t, is.Nil(checkResolverTargets(nil, []discovery.Discoverer{resolver, &testDiscoverer{}})))
+}
+
+type testDiscoverer struct {
+	started bool
+	targets []check.Address
+}
+
+func (d *testDiscoverer) Name() string {
+	return "test"
+}
+
+func (d *testDiscoverer) Start(_ context.Context) error {
+	d.started = true
+	return nil
+}
+
+func (d *testDiscoverer) Targets() []check.Address {
+	return d.targets
+}
+
+type testCheckDiscoverer struct {
+	testDiscoverer
+	checks []check.Check
+}
+
+func (d *testCheckDiscoverer) Checks() []check.Check {
+	return d.checks
+}
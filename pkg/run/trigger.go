@@ -0,0 +1,212 @@
+package run
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bakito/dns-checker/pkg/check"
+	"github.com/bakito/dns-checker/pkg/discovery"
+	"github.com/bakito/dns-checker/pkg/status"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// envAPIToken the bearer token required to trigger checks; the trigger endpoint is disabled if not set
+	envAPIToken = "API_TOKEN"
+	// envTriggerAllowAdHoc allow triggering checks for hosts not in the configured target list
+	envTriggerAllowAdHoc = "TRIGGER_ALLOW_AD_HOC"
+)
+
+var activeTrigger atomic.Pointer[trigger]
+
+// TriggerHandler the http handler to trigger checks on demand
+func TriggerHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t := activeTrigger.Load()
+		if t == nil {
+			http.Error(w, "checks not started yet", http.StatusServiceUnavailable)
+			return
+		}
+		t.ServeHTTP(w, r)
+	})
+}
+
+type trigger struct {
+	ctx         context.Context
+	interval    time.Duration
+	token       string
+	allowAdHoc  bool
+	collector   collector
+	resultsChan chan execution
+	targets     *targetSet
+	checks      []check.Check
+}
+
+type triggerRequest struct {
+	Target string   `json:"target"`
+	Checks []string `json:"checks,omitempty"`
+}
+
+type triggerResult struct {
+	Target   string  `json:"target"`
+	Port     *int    `json:"port,omitempty"`
+	Check    string  `json:"check"`
+	OK       bool    `json:"ok"`
+	Error    string  `json:"error,omitempty"`
+	Duration float64 `json:"duration"`
+	TimedOut bool    `json:"timedOut"`
+	WorkerID int     `json:"worker"`
+}
+
+func newTrigger(ctx context.Context, interval time.Duration, collector collector, resultsChan chan execution,
+	targets *targetSet, checks []check.Check) *trigger {
+	return &trigger{
+		ctx:         ctx,
+		interval:    interval,
+		token:       os.Getenv(envAPIToken),
+		allowAdHoc:  boolEnv(envTriggerAllowAdHoc),
+		collector:   collector,
+		resultsChan: resultsChan,
+		targets:     targets,
+		checks:      checks,
+	}
+}
+
+func (t *trigger) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if t.token == "" {
+		http.Error(w, fmt.Sprintf("triggering checks is disabled, %s is not set", envAPIToken), http.StatusForbidden)
+		return
+	}
+	if !t.authorized(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req triggerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	// request targets are not expanded from env variables, to not expose the environment of the checker
+	if strings.Contains(req.Target, "${") {
+		http.Error(w, fmt.Sprintf("invalid target %q", req.Target), http.StatusBadRequest)
+		return
+	}
+	address, err := discovery.ParseAddress(req.Target)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid target %q", req.Target), http.StatusBadRequest)
+		return
+	}
+	configured := t.targets.contains(address)
+	if !configured && !t.allowAdHoc {
+		http.Error(w, fmt.Sprintf("target %q is not configured", req.Target), http.StatusForbidden)
+		return
+	}
+	checks, err := t.selectChecks(req.Checks)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	log.WithFields(log.Fields{"target": req.Target, "checks": req.Checks, "ad-hoc": !configured}).Info("Triggering checks")
+	executions, err := t.run(r.Context(), address, checks)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusGatewayTimeout)
+		return
+	}
+
+	results := make([]triggerResult, 0, len(executions))
+	for _, e := range executions {
+		// only configured targets are reported, to not create series for ad-hoc hosts
+		if configured {
+			t.resultsChan <- e
+		}
+		results = append(results, toTriggerResult(e))
+	}
+	status.WriteJSON(w, results)
+}
+
+// run dispatch the checks through the worker pool and wait until all are completed
+func (t *trigger) run(ctx context.Context, address check.Address, checks []check.Check) ([]execution, error) {
+	round := &sync.WaitGroup{}
+	round.Add(len(checks))
+	results := make(chan execution, len(checks))
+	for _, chk := range checks {
+		select {
+		case t.collector.work <- work{t.ctx, t.interval, results, address, chk, round, ""}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		round.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	close(results)
+	var executions []execution
+	for e := range results {
+		executions = append(executions, e)
+	}
+	return executions, nil
+}
+
+func (t *trigger) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(t.token)) == 1
+}
+
+// selectChecks select the enabled and discovered checks by name, all if no names are given
+func (t *trigger) selectChecks(names []string) ([]check.Check, error) {
+	checks := t.targets.checks(t.checks)
+	if len(names) == 0 {
+		return checks, nil
+	}
+	var selected []check.Check
+	for _, n := range names {
+		idx := slices.IndexFunc(checks, func(c check.Check) bool { return c.Name() == n })
+		if idx < 0 {
+			return nil, fmt.Errorf("check %q is not enabled", n)
+		}
+		selected = append(selected, checks[idx])
+	}
+	return selected, nil
+}
+
+func toTriggerResult(e execution) triggerResult {
+	r := triggerResult{
+		Target:   e.Host,
+		Port:     e.Port,
+		Check:    e.check.Name(),
+		OK:       e.Err == nil,
+		TimedOut: e.TimedOut,
+		WorkerID: e.WorkerID,
+	}
+	if e.Err != nil {
+		r.Error = e.Err.Error()
+	}
+	if e.Duration != nil {
+		r.Duration = float64(*e.Duration) / float64(time.Millisecond)
+	}
+	return r
+}
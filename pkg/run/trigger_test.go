@@ -0,0 +1,107 @@
+package run
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bakito/dns-checker/pkg/check"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func Test_trigger(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	port := 80
+	resultsChan := make(chan execution, 10)
+	tr := newTrigger(ctx, time.Second, startDispatcher(2), resultsChan,
+		newTargetSet([]check.Address{{Host: "configured", Port: &port}}),
+		[]check.Check{&testCheck{name: "ok"}, &testCheck{name: "nok", err: errors.New("failed")}})
+	tr.token = "secret"
+
+	rec := triggerRequestRecorder(tr, "", `{"target":"configured:80"}`)
+	assert.Assert(t, is.Equal(rec.Code, http.StatusUnauthorized))
+
+	rec = triggerRequestRecorder(tr, "secret", `{"target":"other"}`)
+	assert.Assert(t, is.Equal(rec.Code, http.StatusForbidden))
+
+	rec = triggerRequestRecorder(tr, "secret", `{"target":"configured:80","checks":["unknown"]}`)
+	assert.Assert(t, is.Equal(rec.Code, http.StatusBadRequest))
+	assert.Assert(t, is.Contains(rec.Body.String(), `check "unknown" is not enabled`))
+
+	rec = triggerRequestRecorder(tr, "secret", `{"target":"configured:80"}`)
+	assert.Assert(t, is.Equal(rec.Code, http.StatusOK))
+	var results []triggerResult
+	assert.Assert(t, is.Nil(json.Unmarshal(rec.Body.Bytes(), &results)))
+	assert.Assert(t, is.Len(results, 2))
+	assert.Assert(t, is.Len(resultsChan, 2))
+	for _, r := range results {
+		assert.Assert(t, is.Equal(r.Target, "configured"))
+		assert.Assert(t, is.Equal(*r.Port, 80))
+		assert.Assert(t, is.Equal(r.OK, r.Check == "ok"))
+	}
+
+	tr.allowAdHoc = true
+	rec = triggerRequestRecorder(tr, "secret", `{"target":"other","checks":["nok"]}`)
+	assert.Assert(t, is.Equal(rec.Code, http.StatusOK))
+	results = nil
+	assert.Assert(t, is.Nil(json.Unmarshal(rec.Body.Bytes(), &results)))
+	assert.Assert(t, is.Len(results, 1))
+	assert.Assert(t, is.Equal(results[0].Error, "failed"))
+	// ad-hoc results are not reported
+	assert.Assert(t, is.Len(resultsChan, 2))
+
+	// request targets are not expanded from env variables
+	t.Setenv(testEnv, "secret-value")
+	rec = triggerRequestRecorder(tr, "secret", `{"target":"${`+testEnv+`}","checks":["ok"]}`)
+	assert.Assert(t, is.Equal(rec.Code, http.StatusBadRequest))
+	assert.Assert(t, !strings.Contains(rec.Body.String(), "secret-value"))
+	rec = triggerRequestRecorder(tr, "secret", `{"target":"other:${`+testEnv+`}","checks":["ok"]}`)
+	assert.Assert(t, is.Equal(rec.Code, http.StatusBadRequest))
+
+	// discovered checks can be selected
+	tr.targets = newTargetSet(tr.targets.static, &testCheckDiscoverer{checks: []check.Check{&testCheck{name: "manual_dns@10.0.0.10"}}})
+	rec = triggerRequestRecorder(tr, "secret", `{"target":"configured:80","checks":["manual_dns@10.0.0.10"]}`)
+	assert.Assert(t, is.Equal(rec.Code, http.StatusOK))
+	results = nil
+	assert.Assert(t, is.Nil(json.Unmarshal(rec.Body.Bytes(), &results)))
+	assert.Assert(t, is.Len(results, 1))
+	assert.Assert(t, is.Equal(results[0].Check, "manual_dns@10.0.0.10"))
+}
+
+func Test_trigger_disabled(t *testing.T) {
+	tr := &trigger{}
+	rec := triggerRequestRecorder(tr, "", `{"target":"configured:80"}`)
+	assert.Assert(t, is.Equal(rec.Code, http.StatusForbidden))
+}
+
+func triggerRequestRecorder(tr *trigger, token string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/check", strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	tr.ServeHTTP(rec, req)
+	return rec
+}
+
+type testCheck struct {
+	check.BaseCheck
+	name string
+	err  error
+}
+
+func (c *testCheck) Name() string {
+	return c.name
+}
+
+func (c *testCheck) Run(_ context.Context, _ check.Address) *check.Result {
+	return &check.Result{Err: c.err}
+}
@@ -15,5 +15,8 @@ func newExecution(check check.Check, address check.Address) execution {
 type execution struct {
 	check.Result
 	check.Address
-	check check.Check
+	check    check.Check
+	resource string
+	// skipped the check does not apply to the address; only reported to the controller of the resource
+	skipped bool
 }
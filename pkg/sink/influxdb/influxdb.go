@@ -0,0 +1,342 @@
+package influxdb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bakito/dns-checker/pkg/check"
+	"github.com/bakito/dns-checker/version"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// Name the name of this sink
+	Name = "influxdb"
+
+	// envInfluxURL the output of the line protocol; a http(s) write endpoint (e.g. http://influx:8086/api/v2/write?org=o&bucket=b),
+	// udp://host:port or file:///path/to/file
+	envInfluxURL         = "INFLUXDB_URL"
+	envInfluxToken       = "INFLUXDB_TOKEN"
+	envInfluxMeasurement = "INFLUXDB_MEASUREMENT"
+	// envInfluxTags a ',' separated list of '=' separated constant tags. E.g: "env=prod,zone=a"
+	envInfluxTags          = "INFLUXDB_TAGS"
+	envInfluxBatchSize     = "INFLUXDB_BATCH_SIZE"
+	envInfluxFlushInterval = "INFLUXDB_FLUSH_INTERVAL"
+	envInfluxTimeout       = "INFLUXDB_TIMEOUT"
+
+	defaultMeasurement   = "dns_checker_check"
+	defaultBatchSize     = 100
+	defaultFlushInterval = 10 * time.Second
+	// udpPayloadSize the max size of an udp datagram; fits into the common MTU of 1500 bytes
+	udpPayloadSize = 1400
+)
+
+type writer interface {
+	write(lines []byte) error
+}
+
+// New create a new influxdb sink configured by env variables
+func New() (check.Sink, error) {
+	rawURL, exists := os.LookupEnv(envInfluxURL)
+	if !exists {
+		return nil, fmt.Errorf("%q must be defined to use the %s sink", envInfluxURL, Name)
+	}
+	s := &sink{
+		measurement:   defaultMeasurement,
+		batchSize:     defaultBatchSize,
+		flushInterval: defaultFlushInterval,
+		flushChan:     make(chan struct{}, 1),
+		done:          make(chan struct{}),
+	}
+	if m, exists := os.LookupEnv(envInfluxMeasurement); exists {
+		s.measurement = m
+	}
+	var err error
+	if b, exists := os.LookupEnv(envInfluxBatchSize); exists {
+		if s.batchSize, err = strconv.Atoi(b); err != nil || s.batchSize < 1 {
+			return nil, fmt.Errorf("env var %s %q must be a positive int", envInfluxBatchSize, b)
+		}
+	}
+	if f, exists := os.LookupEnv(envInfluxFlushInterval); exists {
+		if s.flushInterval, err = time.ParseDuration(f); err != nil || s.flushInterval <= 0 {
+			return nil, fmt.Errorf("env var %s %q must be a positive duration", envInfluxFlushInterval, f)
+		}
+	}
+	timeout := 10 * time.Second
+	if to, exists := os.LookupEnv(envInfluxTimeout); exists {
+		if timeout, err = time.ParseDuration(to); err != nil {
+			return nil, fmt.Errorf("env var %s %q can not be parsed as duration", envInfluxTimeout, to)
+		}
+	}
+	if s.tags, err = parseTags(os.Getenv(envInfluxTags)); err != nil {
+		return nil, err
+	}
+	if s.writer, err = newWriter(rawURL, os.Getenv(envInfluxToken), timeout); err != nil {
+		return nil, err
+	}
+
+	log.WithFields(log.Fields{"url": redact(rawURL), "batch": s.batchSize, "flush": fmt.Sprintf("%v", s.flushInterval)}).
+		Info("Setup influxdb sink")
+	return s, nil
+}
+
+func newWriter(rawURL string, token string, timeout time.Duration) (writer, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("env var %s %q can not be parsed as url: %w", envInfluxURL, rawURL, err)
+	}
+	switch u.Scheme {
+	case "http", "https":
+		return &httpWriter{url: rawURL, token: token, client: &http.Client{Timeout: timeout}}, nil
+	case "udp":
+		conn, err := net.Dial("udp", u.Host)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to influxdb %q: %w", u.Host, err)
+		}
+		return &udpWriter{conn: conn, payloadSize: udpPayloadSize}, nil
+	case "file":
+		path := u.Path
+		if path == "" {
+			path = u.Opaque
+		}
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open influxdb output file %q: %w", path, err)
+		}
+		return &fileWriter{file: f}, nil
+	}
+	return nil, fmt.Errorf("env var %s contains unsupported scheme %q", envInfluxURL, u.Scheme)
+}
+
+type sink struct {
+	writer        writer
+	measurement   string
+	tags          [][2]string
+	batchSize     int
+	flushInterval time.Duration
+	// flushChan signals a full batch to the flush loop
+	flushChan chan struct{}
+	done      chan struct{}
+
+	mux   sync.Mutex
+	lines [][]byte
+}
+
+// Start the flush loop; the pending lines are written when the context is done
+func (s *sink) Start(ctx context.Context) {
+	go s.flushLoop(ctx)
+}
+
+// Wait until the pending lines are written after the context is done
+func (s *sink) Wait() {
+	<-s.done
+}
+
+// Report add the result as line to the current batch; the batch is written by the flush loop when full or on the next flush interval
+func (s *sink) Report(name string, address check.Address, result check.Result) {
+	l := s.line(name, address, result, time.Now())
+
+	s.mux.Lock()
+	s.lines = append(s.lines, l)
+	full := len(s.lines) >= s.batchSize
+	s.mux.Unlock()
+
+	if full {
+		select {
+		case s.flushChan <- struct{}{}:
+		default:
+			// a flush is already pending
+		}
+	}
+}
+
+func (s *sink) flushLoop(ctx context.Context) {
+	defer close(s.done)
+	ticker := time.NewTicker(s.flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.flushChan:
+			s.flush()
+		case <-ticker.C:
+			s.flush()
+		case <-ctx.Done():
+			s.flush()
+			return
+		}
+	}
+}
+
+func (s *sink) flush() {
+	s.mux.Lock()
+	lines := s.lines
+	s.lines = nil
+	s.mux.Unlock()
+
+	if len(lines) == 0 {
+		return
+	}
+	if err := s.writer.write(bytes.Join(lines, []byte("\n"))); err != nil {
+		log.WithField("lines", len(lines)).WithError(err).Error("Error writing influxdb lines")
+	}
+}
+
+// line encode the result as influxdb line protocol
+func (s *sink) line(name string, address check.Address, result check.Result, ts time.Time) []byte {
+	var b strings.Builder
+	b.WriteString(escape(s.measurement, ", "))
+	writeTag(&b, "target", address.Host)
+	if address.Port != nil {
+		writeTag(&b, "port", strconv.Itoa(*address.Port))
+	}
+	writeTag(&b, "check_name", name)
+	if check.WithVersion() {
+		writeTag(&b, "version", version.Version)
+	}
+	for _, t := range s.tags {
+		writeTag(&b, t[0], t[1])
+	}
+	for _, l := range check.CustomLabels(name, address) {
+		writeTag(&b, l.Name, l.Value)
+	}
+
+	duration := float64(*result.Duration) / float64(time.Millisecond)
+	b.WriteString(" duration=")
+	b.WriteString(strconv.FormatFloat(duration, 'f', -1, 64))
+	b.WriteString(",error=")
+	b.WriteString(strconv.FormatBool(result.Err != nil))
+	if result.Err != nil {
+		b.WriteString(`,error_text="`)
+		b.WriteString(escape(result.Err.Error(), `"\`))
+		b.WriteString(`"`)
+	}
+	b.WriteString(" ")
+	b.WriteString(strconv.FormatInt(ts.UnixNano(), 10))
+	return []byte(b.String())
+}
+
+func writeTag(b *strings.Builder, key string, value string) {
+	if value == "" {
+		// empty tag values are not allowed in the line protocol
+		return
+	}
+	b.WriteString(",")
+	b.WriteString(escape(key, ",= "))
+	b.WriteString("=")
+	b.WriteString(escape(value, ",= "))
+}
+
+func escape(value string, chars string) string {
+	var b strings.Builder
+	for _, r := range value {
+		if r == '\n' {
+			b.WriteString(`\n`)
+			continue
+		}
+		if strings.ContainsRune(chars, r) {
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func parseTags(value string) ([][2]string, error) {
+	var tags [][2]string
+	if strings.TrimSpace(value) == "" {
+		return tags, nil
+	}
+	for t := range strings.SplitSeq(value, check.Separator) {
+		k, v, ok := strings.Cut(t, "=")
+		if !ok || strings.TrimSpace(k) == "" {
+			return nil, fmt.Errorf("env var %s tag %q is not a key=value pair", envInfluxTags, t)
+		}
+		tags = append(tags, [2]string{strings.TrimSpace(k), strings.TrimSpace(v)})
+	}
+	return tags, nil
+}
+
+func redact(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil {
+		return u.Redacted()
+	}
+	return rawURL
+}
+
+type httpWriter struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func (w *httpWriter) write(lines []byte) error {
+	req, err := http.NewRequest(http.MethodPost, w.url, bytes.NewReader(lines))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if w.token != "" {
+		req.Header.Set("Authorization", "Token "+w.token)
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+		return fmt.Errorf("unexpected response status %q: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+type udpWriter struct {
+	conn        net.Conn
+	payloadSize int
+}
+
+// write the lines split into datagrams of at most payloadSize bytes; longer lines are sent in a datagram of their own
+func (w *udpWriter) write(lines []byte) error {
+	var datagram []byte
+	for l := range bytes.SplitSeq(lines, []byte("\n")) {
+		if len(datagram) > 0 && len(datagram)+1+len(l) > w.payloadSize {
+			if _, err := w.conn.Write(datagram); err != nil {
+				return err
+			}
+			datagram = nil
+		}
+		if len(datagram) > 0 {
+			datagram = append(datagram, '\n')
+		}
+		datagram = append(datagram, l...)
+	}
+	if len(datagram) == 0 {
+		return nil
+	}
+	_, err := w.conn.Write(datagram)
+	return err
+}
+
+type fileWriter struct {
+	mux  sync.Mutex
+	file *os.File
+}
+
+func (w *fileWriter) write(lines []byte) error {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	_, err := w.file.Write(append(lines, '\n'))
+	return err
+}
@@ -0,0 +1,163 @@
+package influxdb
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bakito/dns-checker/pkg/check"
+	"github.com/bakito/dns-checker/version"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func Test_sink_line(t *testing.T) {
+	s := &sink{measurement: "dns checker", tags: [][2]string{{"env", "prod,a"}}}
+	port := 53
+	d := 1500 * time.Microsecond
+	ts := time.Unix(0, 1234)
+
+	l := s.line("probe-port", check.Address{Host: "host.name", Port: &port}, check.Result{Duration: &d, Err: errors.New(`dial "x" failed`)}, ts)
+	assert.Equal(t, string(l), `dns\ checker,target=host.name,port=53,check_name=probe-port,version=`+version.Version+
+		`,env=prod\,a duration=1.5,error=true,error_text="dial \"x\" failed" 1234`)
+
+	l = s.line("dns", check.Address{Host: "host.name"}, check.Result{Duration: &d}, ts)
+	assert.Equal(t, string(l), `dns\ checker,target=host.name,check_name=dns,version=`+version.Version+
+		`,env=prod\,a duration=1.5,error=false 1234`)
+}
+
+func Test_sink_http(t *testing.T) {
+	bodies := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.Header.Get("Authorization"), "Token secret")
+		b, _ := io.ReadAll(r.Body)
+		bodies <- string(b)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	t.Setenv(envInfluxURL, srv.URL+"/api/v2/write?org=o&bucket=b")
+	t.Setenv(envInfluxToken, "secret")
+	t.Setenv(envInfluxBatchSize, "2")
+	t.Setenv(envInfluxFlushInterval, "1h")
+
+	s, err := New()
+	assert.Assert(t, is.Nil(err))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.(check.BackgroundSink).Start(ctx)
+
+	d := time.Millisecond
+	s.Report("dns", check.Address{Host: "a"}, check.Result{Duration: &d})
+	s.Report("dns", check.Address{Host: "b"}, check.Result{Duration: &d})
+
+	select {
+	case b := <-bodies:
+		lines := strings.Split(b, "\n")
+		assert.Assert(t, is.Len(lines, 2))
+		assert.Assert(t, strings.HasPrefix(lines[0], "dns_checker_check,target=a,"))
+		assert.Assert(t, strings.HasPrefix(lines[1], "dns_checker_check,target=b,"))
+	case <-time.After(time.Second):
+		t.Fatal("batch was not written")
+	}
+}
+
+func Test_sink_file(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.lp")
+	t.Setenv(envInfluxURL, "file://"+path)
+	t.Setenv(envInfluxFlushInterval, "10ms")
+
+	s, err := New()
+	assert.Assert(t, is.Nil(err))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.(check.BackgroundSink).Start(ctx)
+
+	d := time.Millisecond
+	s.Report("dns", check.Address{Host: "a"}, check.Result{Duration: &d})
+
+	assert.Assert(t, eventually(func() bool {
+		b, _ := os.ReadFile(path)
+		return strings.HasPrefix(string(b), "dns_checker_check,target=a,") && strings.HasSuffix(string(b), "\n")
+	}))
+}
+
+func Test_sink_flushOnShutdown(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.lp")
+	t.Setenv(envInfluxURL, "file://"+path)
+	t.Setenv(envInfluxFlushInterval, "1h")
+
+	s, err := New()
+	assert.Assert(t, is.Nil(err))
+	ctx, cancel := context.WithCancel(context.Background())
+	s.(check.BackgroundSink).Start(ctx)
+
+	d := time.Millisecond
+	s.Report("dns", check.Address{Host: "a"}, check.Result{Duration: &d})
+	cancel()
+	s.(check.BackgroundSink).Wait()
+
+	b, err := os.ReadFile(path)
+	assert.Assert(t, is.Nil(err))
+	assert.Assert(t, strings.HasPrefix(string(b), "dns_checker_check,target=a,"))
+}
+
+func Test_New_invalid(t *testing.T) {
+	_, err := New()
+	assert.Assert(t, is.Error(err, `"INFLUXDB_URL" must be defined to use the influxdb sink`))
+
+	t.Setenv(envInfluxURL, "tcp://localhost:8089")
+	_, err = New()
+	assert.Assert(t, is.Error(err, `env var INFLUXDB_URL contains unsupported scheme "tcp"`))
+
+	t.Setenv(envInfluxURL, "udp://localhost:8089")
+	t.Setenv(envInfluxBatchSize, "0")
+	_, err = New()
+	assert.Assert(t, is.Error(err, `env var INFLUXDB_BATCH_SIZE "0" must be a positive int`))
+
+	t.Setenv(envInfluxBatchSize, "10")
+	t.Setenv(envInfluxFlushInterval, "0s")
+	_, err = New()
+	assert.Assert(t, is.Error(err, `env var INFLUXDB_FLUSH_INTERVAL "0s" must be a positive duration`))
+}
+
+func Test_udpWriter(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Assert(t, is.Nil(err))
+	defer func() {
+		_ = pc.Close()
+	}()
+	conn, err := net.Dial("udp", pc.LocalAddr().String())
+	assert.Assert(t, is.Nil(err))
+	w := &udpWriter{conn: conn, payloadSize: 10}
+
+	assert.Assert(t, is.Nil(w.write([]byte("aaaa\nbbbb\ncccccccccccc\nd"))))
+
+	var datagrams []string
+	buf := make([]byte, 100)
+	for range 3 {
+		assert.Assert(t, is.Nil(pc.SetReadDeadline(time.Now().Add(time.Second))))
+		n, _, err := pc.ReadFrom(buf)
+		assert.Assert(t, is.Nil(err))
+		datagrams = append(datagrams, string(buf[:n]))
+	}
+	assert.Assert(t, is.DeepEqual(datagrams, []string{"aaaa\nbbbb", "cccccccccccc", "d"}))
+}
+
+func eventually(cond func() bool) bool {
+	for range 100 {
+		if cond() {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
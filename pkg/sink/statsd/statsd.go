@@ -0,0 +1,136 @@
+package statsd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bakito/dns-checker/pkg/check"
+	"github.com/bakito/dns-checker/version"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// Name the name of this sink
+	Name = "statsd"
+
+	envStatsdAddress = "STATSD_ADDRESS"
+	envStatsdPrefix  = "STATSD_PREFIX"
+	// envStatsdFlavor the statsd flavor; dogstatsd sends tags, plain statsd encodes the check and target in the metric name
+	envStatsdFlavor = "STATSD_FLAVOR"
+	// envStatsdTags a ',' separated list of ':' separated constant tags. E.g: "env:prod,team:network"
+	envStatsdTags = "STATSD_TAGS"
+
+	flavorDogStatsd = "dogstatsd"
+	flavorStatsd    = "statsd"
+
+	defaultAddress = "127.0.0.1:8125"
+	defaultPrefix  = "dns_checker.check"
+)
+
+var invalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9_\-]`)
+
+// New create a new statsd sink configured by env variables
+func New() (check.Sink, error) {
+	address := defaultAddress
+	if a, exists := os.LookupEnv(envStatsdAddress); exists {
+		address = a
+	}
+	prefix := defaultPrefix
+	if p, exists := os.LookupEnv(envStatsdPrefix); exists {
+		prefix = p
+	}
+	flavor := flavorDogStatsd
+	if f, exists := os.LookupEnv(envStatsdFlavor); exists {
+		flavor = strings.TrimSpace(f)
+	}
+	if flavor != flavorDogStatsd && flavor != flavorStatsd {
+		return nil, fmt.Errorf("env var %s contains unknown flavor %q", envStatsdFlavor, flavor)
+	}
+	var tags []string
+	if t, exists := os.LookupEnv(envStatsdTags); exists {
+		for tag := range strings.SplitSeq(t, check.Separator) {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to statsd %q: %w", address, err)
+	}
+	log.WithFields(log.Fields{"address": address, "flavor": flavor}).Info("Setup statsd sink")
+	return &sink{conn: conn, prefix: prefix, dogStatsd: flavor == flavorDogStatsd, tags: tags}, nil
+}
+
+type sink struct {
+	conn      net.Conn
+	prefix    string
+	dogStatsd bool
+	tags      []string
+}
+
+// Report send the result as error gauge, duration timing and run / failure counters in a single packet
+func (s *sink) Report(name string, address check.Address, result check.Result) {
+	duration := float64(*result.Duration) / float64(time.Millisecond)
+	errorValue := 0
+	if result.Err != nil {
+		errorValue = 1
+	}
+
+	lines := []string{
+		s.line("error", fmt.Sprintf("%d|g", errorValue), name, address),
+		s.line("duration", fmt.Sprintf("%f|ms", duration), name, address),
+		s.line("runs", "1|c", name, address),
+	}
+	if result.Err != nil {
+		lines = append(lines, s.line("failures", "1|c", name, address))
+	}
+
+	if _, err := s.conn.Write([]byte(strings.Join(lines, "\n"))); err != nil {
+		log.WithError(err).Debug("Error sending statsd metrics")
+	}
+}
+
+func (s *sink) line(metric string, value string, name string, address check.Address) string {
+	if !s.dogStatsd {
+		return fmt.Sprintf("%s.%s.%s.%s:%s", s.prefix, sanitize(name), sanitize(target(address)), metric, value)
+	}
+	tags := []string{
+		"target:" + address.Host,
+		"port:" + port(address),
+		"check_name:" + name,
+	}
+	if check.WithVersion() {
+		tags = append(tags, "version:"+version.Version)
+	}
+	tags = append(tags, s.tags...)
+	for _, l := range check.CustomLabels(name, address) {
+		if l.Value != "" {
+			tags = append(tags, l.Name+":"+l.Value)
+		}
+	}
+	return fmt.Sprintf("%s.%s:%s|#%s", s.prefix, metric, value, strings.Join(tags, ","))
+}
+
+func target(address check.Address) string {
+	if address.Port != nil {
+		return fmt.Sprintf("%s_%d", address.Host, *address.Port)
+	}
+	return address.Host
+}
+
+func port(address check.Address) string {
+	if address.Port != nil {
+		return fmt.Sprintf("%d", *address.Port)
+	}
+	return ""
+}
+
+func sanitize(value string) string {
+	return invalidNameChars.ReplaceAllString(value, "_")
+}
@@ -0,0 +1,75 @@
+package statsd
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bakito/dns-checker/pkg/check"
+	"github.com/bakito/dns-checker/version"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func Test_sink_dogStatsd(t *testing.T) {
+	conn := listen(t)
+	t.Setenv(envStatsdAddress, conn.LocalAddr().String())
+	t.Setenv(envStatsdTags, "env:test")
+
+	s, err := New()
+	assert.Assert(t, is.Nil(err))
+
+	port := 53
+	d := 1500 * time.Microsecond
+	s.Report("dns", check.Address{Host: "host.name", Port: &port}, check.Result{Duration: &d, Err: errors.New("failed")})
+
+	tags := "|#target:host.name,port:53,check_name:dns,version:" + version.Version + ",env:test"
+	assert.Assert(t, is.DeepEqual(read(t, conn), []string{
+		"dns_checker.check.error:1|g" + tags,
+		"dns_checker.check.duration:1.500000|ms" + tags,
+		"dns_checker.check.runs:1|c" + tags,
+		"dns_checker.check.failures:1|c" + tags,
+	}))
+}
+
+func Test_sink_statsd(t *testing.T) {
+	conn := listen(t)
+	t.Setenv(envStatsdAddress, conn.LocalAddr().String())
+	t.Setenv(envStatsdFlavor, flavorStatsd)
+	t.Setenv(envStatsdPrefix, "checker")
+
+	s, err := New()
+	assert.Assert(t, is.Nil(err))
+
+	d := 2 * time.Millisecond
+	s.Report("probe-port", check.Address{Host: "host.name"}, check.Result{Duration: &d})
+
+	assert.Assert(t, is.DeepEqual(read(t, conn), []string{
+		"checker.probe-port.host_name.error:0|g",
+		"checker.probe-port.host_name.duration:2.000000|ms",
+		"checker.probe-port.host_name.runs:1|c",
+	}))
+}
+
+func Test_New_invalidFlavor(t *testing.T) {
+	t.Setenv(envStatsdFlavor, "graphite")
+	_, err := New()
+	assert.Assert(t, is.Error(err, `env var STATSD_FLAVOR contains unknown flavor "graphite"`))
+}
+
+func listen(t *testing.T) *net.UDPConn {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	assert.Assert(t, is.Nil(err))
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+func read(t *testing.T, conn *net.UDPConn) []string {
+	buf := make([]byte, 4096)
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, err := conn.Read(buf)
+	assert.Assert(t, is.Nil(err))
+	return strings.Split(string(buf[:n]), "\n")
+}
@@ -0,0 +1,146 @@
+package status
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bakito/dns-checker/pkg/check"
+)
+
+const (
+	// envHistorySize the number of results kept per target check
+	envHistorySize = "HISTORY_SIZE"
+
+	defaultHistorySize = 100
+)
+
+// Record a single result of a target check
+type Record struct {
+	Timestamp time.Time   `json:"timestamp"`
+	OK        bool        `json:"ok"`
+	Error     string      `json:"error,omitempty"`
+	Duration  float64     `json:"duration"`
+	WorkerID  int         `json:"worker"`
+	State     check.State `json:"state"`
+}
+
+// Series the result history of a target check
+type Series struct {
+	Target  string   `json:"target"`
+	Port    *int     `json:"port,omitempty"`
+	Check   string   `json:"check"`
+	Records []Record `json:"records"`
+}
+
+// HistoryHandler the history http handler of the default store
+func HistoryHandler() http.Handler {
+	return http.HandlerFunc(defaultStore.ServeHistory)
+}
+
+// ring a bounded buffer of records, overwriting the oldest record when full
+type ring struct {
+	records []Record
+	start   int
+	size    int
+}
+
+func newRing(capacity int) *ring {
+	return &ring{records: make([]Record, capacity)}
+}
+
+func (r *ring) add(rec Record) {
+	idx := (r.start + r.size) % len(r.records)
+	r.records[idx] = rec
+	if r.size < len(r.records) {
+		r.size++
+	} else {
+		r.start = (r.start + 1) % len(r.records)
+	}
+}
+
+// list the records from oldest to newest, filtered by the time range; zero times are not filtered
+func (r *ring) list(from time.Time, to time.Time) []Record {
+	records := make([]Record, 0, r.size)
+	for i := range r.size {
+		rec := r.records[(r.start+i)%len(r.records)]
+		if (!from.IsZero() && rec.Timestamp.Before(from)) || (!to.IsZero() && rec.Timestamp.After(to)) {
+			continue
+		}
+		records = append(records, rec)
+	}
+	return records
+}
+
+// last the newest n records
+func (r *ring) last(n int) []Record {
+	n = min(n, r.size)
+	records := make([]Record, 0, n)
+	for i := r.size - n; i < r.size; i++ {
+		records = append(records, r.records[(r.start+i)%len(r.records)])
+	}
+	return records
+}
+
+// History the result history of the target checks matching the filters; empty filters match all
+func (s *Store) History(target string, name string, from time.Time, to time.Time) []Series {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	var series []Series
+	for _, e := range s.list() {
+		if name != "" && e.Check != name {
+			continue
+		}
+		if target != "" && target != e.Target && target != fmt.Sprintf("%s:%d", e.Target, port(e.Port)) {
+			continue
+		}
+		series = append(series, Series{
+			Target:  e.Target,
+			Port:    e.Port,
+			Check:   e.Check,
+			Records: s.history[Key(e.Check, check.Address{Host: e.Target, Port: e.Port})].list(from, to),
+		})
+	}
+	return series
+}
+
+// ServeHistory serve the history filtered by the query parameters target, check, from and to.
+// from and to are RFC3339 timestamps or durations relative to now, e.g. from=1h
+func (s *Store) ServeHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+	from, err := parseTime(q.Get("from"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid from: %v", err), http.StatusBadRequest)
+		return
+	}
+	to, err := parseTime(q.Get("to"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid to: %v", err), http.StatusBadRequest)
+		return
+	}
+	series := s.History(q.Get("target"), q.Get("check"), from, to)
+	if series == nil {
+		series = []Series{}
+	}
+	WriteJSON(w, series)
+}
+
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	if sec, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(sec, 0), nil
+	}
+	return time.Parse(time.RFC3339, strings.TrimSpace(value))
+}
@@ -0,0 +1,109 @@
+package status
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bakito/dns-checker/pkg/check"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func Test_ring(t *testing.T) {
+	r := newRing(3)
+	base := time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := range 5 {
+		r.add(Record{Timestamp: base.Add(time.Duration(i) * time.Minute), Duration: float64(i)})
+	}
+
+	records := r.list(time.Time{}, time.Time{})
+	assert.Assert(t, is.Len(records, 3))
+	assert.Assert(t, is.Equal(records[0].Duration, 2.))
+	assert.Assert(t, is.Equal(records[2].Duration, 4.))
+
+	records = r.list(base.Add(3*time.Minute), time.Time{})
+	assert.Assert(t, is.Len(records, 2))
+	records = r.list(time.Time{}, base.Add(3*time.Minute))
+	assert.Assert(t, is.Len(records, 2))
+
+	records = r.last(2)
+	assert.Assert(t, is.Len(records, 2))
+	assert.Assert(t, is.Equal(records[0].Duration, 3.))
+	assert.Assert(t, is.Len(r.last(10), 3))
+}
+
+func Test_Store_History(t *testing.T) {
+	s := NewStore(defaultHistorySize)
+	port := 443
+	d := time.Millisecond
+	s.Update("dns", check.Address{Host: "a", Port: &port}, check.Result{Duration: &d, WorkerID: 2}, check.StateUp, false)
+	s.Update("dns", check.Address{Host: "a", Port: &port}, check.Result{Duration: &d, Err: errors.New("failed")}, check.StateDown, false)
+	s.Update("probe-port", check.Address{Host: "a", Port: &port}, check.Result{Duration: &d}, check.StateUp, false)
+	s.Update("dns", check.Address{Host: "b"}, check.Result{Duration: &d}, check.StateUp, false)
+
+	assert.Assert(t, is.Len(s.History("", "", time.Time{}, time.Time{}), 3))
+	assert.Assert(t, is.Len(s.History("a", "", time.Time{}, time.Time{}), 2))
+	assert.Assert(t, is.Len(s.History("a:443", "", time.Time{}, time.Time{}), 2))
+	assert.Assert(t, is.Len(s.History("a:80", "", time.Time{}, time.Time{}), 0))
+	assert.Assert(t, is.Len(s.History("", "dns", time.Time{}, time.Time{}), 2))
+
+	series := s.History("a", "dns", time.Time{}, time.Time{})
+	assert.Assert(t, is.Len(series, 1))
+	assert.Assert(t, is.Len(series[0].Records, 2))
+	assert.Assert(t, series[0].Records[0].OK)
+	assert.Assert(t, is.Equal(series[0].Records[0].WorkerID, 2))
+	assert.Assert(t, is.Equal(series[0].Records[1].Error, "failed"))
+	assert.Assert(t, is.Equal(series[0].Records[1].State, check.StateDown))
+
+	assert.Assert(t, is.Len(s.History("", "", time.Now().Add(time.Minute), time.Time{})[0].Records, 0))
+}
+
+func Test_Store_ServeHistory(t *testing.T) {
+	s := NewStore(defaultHistorySize)
+	d := time.Millisecond
+	s.Update("dns", check.Address{Host: "a"}, check.Result{Duration: &d}, check.StateUp, false)
+
+	rec := httptest.NewRecorder()
+	s.ServeHistory(rec, httptest.NewRequest(http.MethodGet, "/api/v1/history?target=a&check=dns&from=1h", nil))
+	assert.Assert(t, is.Equal(rec.Code, http.StatusOK))
+	var series []Series
+	assert.Assert(t, is.Nil(json.Unmarshal(rec.Body.Bytes(), &series)))
+	assert.Assert(t, is.Len(series, 1))
+	assert.Assert(t, is.Len(series[0].Records, 1))
+
+	rec = httptest.NewRecorder()
+	s.ServeHistory(rec, httptest.NewRequest(http.MethodGet, "/api/v1/history?target=x", nil))
+	assert.Assert(t, is.Equal(rec.Code, http.StatusOK))
+	assert.Assert(t, is.Equal(rec.Body.String(), "[]\n"))
+
+	rec = httptest.NewRecorder()
+	s.ServeHistory(rec, httptest.NewRequest(http.MethodGet, "/api/v1/history?from=yesterday", nil))
+	assert.Assert(t, is.Equal(rec.Code, http.StatusBadRequest))
+}
+
+func Test_parseTime(t *testing.T) {
+	ts, err := parseTime("2021-01-01T12:00:00Z")
+	assert.Assert(t, is.Nil(err))
+	assert.Assert(t, ts.Equal(time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC)))
+
+	ts, err = parseTime("1609502400")
+	assert.Assert(t, is.Nil(err))
+	assert.Assert(t, ts.Equal(time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC)))
+
+	ts, err = parseTime("1h")
+	assert.Assert(t, is.Nil(err))
+	assert.Assert(t, time.Since(ts) >= time.Hour)
+}
+
+func Test_historySize(t *testing.T) {
+	t.Setenv(envHistorySize, "50")
+	assert.Assert(t, is.Equal(historySize(), 50))
+	t.Setenv(envHistorySize, "10")
+	assert.Assert(t, is.Equal(historySize(), recentDurations))
+	t.Setenv(envHistorySize, "x")
+	assert.Assert(t, is.Equal(historySize(), defaultHistorySize))
+}
@@ -0,0 +1,192 @@
+package status
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bakito/dns-checker/pkg/check"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// envSLOTarget the availability objective as ratio. E.g: "0.999"
+	envSLOTarget = "SLO_TARGET"
+	// envSLOWindows a ',' separated list of rolling windows; durations may use the 'd' suffix for days. An empty value disables the calculation.
+	//  E.g: "5m,1h,1d,30d"
+	envSLOWindows = "SLO_WINDOWS"
+
+	defaultSLOTarget = 0.999
+
+	// sloSlots the number of slots each window is divided into; the window rolls forward one slot at a time
+	sloSlots = 60
+)
+
+var defaultSLOWindows = []time.Duration{5 * time.Minute, time.Hour, 24 * time.Hour, 30 * 24 * time.Hour}
+
+// Availability the availability of a target check over a rolling window
+type Availability struct {
+	Window       string  `json:"window"`
+	Availability float64 `json:"availability"`
+	BurnRate     float64 `json:"burnRate"`
+	Total        int64   `json:"total"`
+	Failed       int64   `json:"failed"`
+}
+
+// Availabilities the availabilities of a check in the default store
+func Availabilities(name string, address check.Address) []Availability {
+	return defaultStore.Availability(name, address)
+}
+
+// WithSLO calculate the availability over the rolling windows and the error budget burn rate against the target
+func (s *Store) WithSLO(target float64, windows []time.Duration) *Store {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.sloTarget = target
+	s.sloWindows = windows
+	return s
+}
+
+// Availability the availabilities of a target check; empty if there are no results within a window
+func (s *Store) Availability(name string, address check.Address) []Availability {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.availability(Key(name, address), time.Now())
+}
+
+func (s *Store) availability(key string, now time.Time) []Availability {
+	w, ok := s.windows[key]
+	if !ok {
+		return nil
+	}
+	var availabilities []Availability
+	for _, win := range w {
+		total, failed := win.count(now)
+		if total == 0 {
+			continue
+		}
+		a := Availability{
+			Window:       formatWindow(win.length),
+			Availability: float64(total-failed) / float64(total),
+			Total:        total,
+			Failed:       failed,
+		}
+		a.BurnRate = (1 - a.Availability) / (1 - s.sloTarget)
+		availabilities = append(availabilities, a)
+	}
+	return availabilities
+}
+
+func (s *Store) recordAvailability(key string, ok bool, now time.Time) {
+	w, exists := s.windows[key]
+	if !exists {
+		for _, length := range s.sloWindows {
+			w = append(w, newWindow(length))
+		}
+		s.windows[key] = w
+	}
+	for _, win := range w {
+		win.add(ok, now)
+	}
+}
+
+// window counts the results of a rolling window in fixed slots
+type window struct {
+	length time.Duration
+	width  int64
+	slots  [sloSlots]slot
+}
+
+type slot struct {
+	index  int64
+	total  int64
+	failed int64
+}
+
+func newWindow(length time.Duration) *window {
+	return &window{length: length, width: max(int64(length)/sloSlots, 1)}
+}
+
+func (w *window) add(ok bool, now time.Time) {
+	idx := now.UnixNano() / w.width
+	s := &w.slots[idx%sloSlots]
+	if s.index != idx {
+		*s = slot{index: idx}
+	}
+	s.total++
+	if !ok {
+		s.failed++
+	}
+}
+
+func (w *window) count(now time.Time) (total int64, failed int64) {
+	idx := now.UnixNano() / w.width
+	for _, s := range w.slots {
+		if s.index > idx-sloSlots && s.index <= idx {
+			total += s.total
+			failed += s.failed
+		}
+	}
+	return total, failed
+}
+
+func formatWindow(d time.Duration) string {
+	switch {
+	case d%(24*time.Hour) == 0:
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	}
+	return d.String()
+}
+
+func parseWindow(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		d, err := strconv.Atoi(days)
+		if err != nil || d <= 0 {
+			return 0, fmt.Errorf("window %q can not be parsed as days", value)
+		}
+		return time.Duration(d) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("window %q can not be parsed as duration", value)
+	}
+	return d, nil
+}
+
+func sloTarget() float64 {
+	if value, exists := os.LookupEnv(envSLOTarget); exists {
+		if t, err := strconv.ParseFloat(value, 64); err == nil && t > 0 && t < 1 {
+			return t
+		}
+		log.WithFields(log.Fields{"env": envSLOTarget, "value": value, "default": defaultSLOTarget}).
+			Warn("could not parse the slo target, using the default")
+	}
+	return defaultSLOTarget
+}
+
+func sloWindows() []time.Duration {
+	value, exists := os.LookupEnv(envSLOWindows)
+	if !exists {
+		return defaultSLOWindows
+	}
+	var windows []time.Duration
+	for w := range strings.SplitSeq(value, check.Separator) {
+		if w = strings.TrimSpace(w); w == "" {
+			continue
+		}
+		d, err := parseWindow(w)
+		if err != nil {
+			log.WithFields(log.Fields{"env": envSLOWindows, "value": value, "default": defaultSLOWindows}).
+				WithError(err).Warn("could not parse the slo windows, using the default")
+			return defaultSLOWindows
+		}
+		windows = append(windows, d)
+	}
+	return windows
+}
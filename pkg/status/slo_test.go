@@ -0,0 +1,92 @@
+package status
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bakito/dns-checker/pkg/check"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func Test_window(t *testing.T) {
+	w := newWindow(time.Minute)
+	now := time.Unix(1000, 0)
+
+	w.add(true, now)
+	w.add(false, now.Add(10*time.Second))
+	w.add(true, now.Add(59*time.Second))
+
+	total, failed := w.count(now.Add(59 * time.Second))
+	assert.Assert(t, is.Equal(total, int64(3)))
+	assert.Assert(t, is.Equal(failed, int64(1)))
+
+	// the first result rolled out of the window
+	total, failed = w.count(now.Add(60 * time.Second))
+	assert.Assert(t, is.Equal(total, int64(2)))
+	assert.Assert(t, is.Equal(failed, int64(1)))
+
+	total, _ = w.count(now.Add(10 * time.Minute))
+	assert.Assert(t, is.Equal(total, int64(0)))
+
+	// a reused slot is reset
+	w.add(true, now.Add(time.Minute))
+	total, failed = w.count(now.Add(time.Minute))
+	assert.Assert(t, is.Equal(total, int64(3)))
+	assert.Assert(t, is.Equal(failed, int64(1)))
+}
+
+func Test_Store_Availability(t *testing.T) {
+	s := NewStore(10).WithSLO(0.99, []time.Duration{5 * time.Minute, 24 * time.Hour})
+	addr := check.Address{Host: "a"}
+
+	assert.Assert(t, is.Len(s.Availability("dns", addr), 0))
+
+	for range 9 {
+		s.Update("dns", addr, check.Result{Duration: new(time.Millisecond)}, check.StateUp, false)
+	}
+	s.Update("dns", addr, check.Result{Duration: new(time.Millisecond), Err: errors.New("failed")}, check.StateUp, false)
+
+	a := s.Availability("dns", addr)
+	assert.Assert(t, is.Len(a, 2))
+	assert.Assert(t, is.Equal(a[0].Window, "5m"))
+	assert.Assert(t, is.Equal(a[1].Window, "1d"))
+	assert.Assert(t, is.Equal(a[0].Availability, 0.9))
+	assert.Assert(t, is.Equal(a[0].Total, int64(10)))
+	assert.Assert(t, is.Equal(a[0].Failed, int64(1)))
+	assert.Assert(t, a[0].BurnRate > 9.99 && a[0].BurnRate < 10.01)
+
+	entries := s.List()
+	assert.Assert(t, is.Len(entries, 1))
+	assert.Assert(t, is.DeepEqual(entries[0].SLO, a))
+}
+
+func Test_parseWindow(t *testing.T) {
+	for value, expected := range map[string]time.Duration{
+		"5m":  5 * time.Minute,
+		"1h":  time.Hour,
+		"1d":  24 * time.Hour,
+		"30d": 30 * 24 * time.Hour,
+	} {
+		d, err := parseWindow(value)
+		assert.Assert(t, is.Nil(err))
+		assert.Assert(t, is.Equal(d, expected))
+		assert.Assert(t, is.Equal(formatWindow(d), value))
+	}
+	_, err := parseWindow("xd")
+	assert.Assert(t, is.Error(err, `window "xd" can not be parsed as days`))
+	_, err = parseWindow("-1m")
+	assert.Assert(t, is.Error(err, `window "-1m" can not be parsed as duration`))
+	assert.Assert(t, is.Equal(formatWindow(90*time.Second), "1m30s"))
+}
+
+func Test_sloWindows(t *testing.T) {
+	assert.Assert(t, is.DeepEqual(sloWindows(), defaultSLOWindows))
+	t.Setenv(envSLOWindows, "1h, 7d")
+	assert.Assert(t, is.DeepEqual(sloWindows(), []time.Duration{time.Hour, 7 * 24 * time.Hour}))
+	t.Setenv(envSLOWindows, "")
+	assert.Assert(t, is.Len(sloWindows(), 0))
+	t.Setenv(envSLOWindows, "foo")
+	assert.Assert(t, is.DeepEqual(sloWindows(), defaultSLOWindows))
+}
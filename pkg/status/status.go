@@ -0,0 +1,201 @@
+package status
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bakito/dns-checker/pkg/check"
+	log "github.com/sirupsen/logrus"
+)
+
+// recentDurations the number of recent durations from the history added to each entry
+const recentDurations = 30
+
+var defaultStore = NewStore(historySize()).WithSLO(sloTarget(), sloWindows())
+
+// Entry the latest status of a target check
+type Entry struct {
+	Target      string         `json:"target"`
+	Port        *int           `json:"port,omitempty"`
+	Check       string         `json:"check"`
+	OK          bool           `json:"ok"`
+	Error       string         `json:"error,omitempty"`
+	Duration    float64        `json:"duration"`
+	Durations   []float64      `json:"durations"`
+	WorkerID    int            `json:"worker"`
+	LastRun     time.Time      `json:"lastRun"`
+	LastSuccess *time.Time     `json:"lastSuccess,omitempty"`
+	LastFailure *time.Time     `json:"lastFailure,omitempty"`
+	State       check.State    `json:"state"`
+	Flapping    bool           `json:"flapping"`
+	SLO         []Availability `json:"slo,omitempty"`
+}
+
+// Update update the status of a check in the default store
+func Update(name string, address check.Address, result check.Result, state check.State, flapping bool) {
+	defaultStore.Update(name, address, result, state, flapping)
+}
+
+// Delete delete the status of a check from the default store
+func Delete(name string, address check.Address) {
+	defaultStore.Delete(name, address)
+}
+
+// Handler the http handler of the default store
+func Handler() http.Handler {
+	return defaultStore
+}
+
+// NewStore create a new status store keeping historySize results per target check; at least the recent durations are kept
+func NewStore(historySize int) *Store {
+	return &Store{
+		entries:     make(map[string]*Entry),
+		history:     make(map[string]*ring),
+		windows:     make(map[string][]*window),
+		historySize: max(historySize, recentDurations),
+	}
+}
+
+// Store holds the latest status and the result history per target check
+type Store struct {
+	lock        sync.RWMutex
+	entries     map[string]*Entry
+	history     map[string]*ring
+	historySize int
+	windows     map[string][]*window
+	sloTarget   float64
+	sloWindows  []time.Duration
+}
+
+// Update update the status of a check with the latest result
+func (s *Store) Update(name string, address check.Address, result check.Result, state check.State, flapping bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	key := Key(name, address)
+	e, ok := s.entries[key]
+	if !ok {
+		e = &Entry{Target: address.Host, Port: address.Port, Check: name}
+		s.entries[key] = e
+		s.history[key] = newRing(s.historySize)
+	}
+
+	now := time.Now()
+	e.LastRun = now
+	e.OK = result.Err == nil
+	e.WorkerID = result.WorkerID
+	e.State = state
+	e.Flapping = flapping
+	if result.Duration != nil {
+		e.Duration = float64(*result.Duration) / float64(time.Millisecond)
+	}
+	if result.Err != nil {
+		e.Error = result.Err.Error()
+		e.LastFailure = &now
+	} else {
+		e.Error = ""
+		e.LastSuccess = &now
+	}
+
+	s.history[key].add(Record{
+		Timestamp: now,
+		OK:        e.OK,
+		Error:     e.Error,
+		Duration:  e.Duration,
+		WorkerID:  e.WorkerID,
+		State:     state,
+	})
+	s.recordAvailability(key, e.OK, now)
+}
+
+// Delete delete the status, history and availability of a check
+func (s *Store) Delete(name string, address check.Address) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	key := Key(name, address)
+	delete(s.entries, key)
+	delete(s.history, key)
+	delete(s.windows, key)
+}
+
+// List all entries sorted by target, port and check
+func (s *Store) List() []Entry {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.list()
+}
+
+func (s *Store) list() []Entry {
+	entries := make([]Entry, 0, len(s.entries))
+	now := time.Now()
+	for key, e := range s.entries {
+		c := *e
+		c.SLO = s.availability(key, now)
+		for _, r := range s.history[key].last(recentDurations) {
+			c.Durations = append(c.Durations, r.Duration)
+		}
+		entries = append(entries, c)
+	}
+	slices.SortFunc(entries, func(a, b Entry) int {
+		return cmp.Or(
+			cmp.Compare(a.Target, b.Target),
+			cmp.Compare(port(a.Port), port(b.Port)),
+			cmp.Compare(a.Check, b.Check),
+		)
+	})
+	return entries
+}
+
+func (s *Store) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	WriteJSON(w, s.List())
+}
+
+// WriteJSON write the value as json response
+func WriteJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.WithError(err).Error("Error writing response")
+	}
+}
+
+// Key the unique key of a target check
+func Key(name string, address check.Address) string {
+	if address.Port != nil {
+		return fmt.Sprintf("%s|%s:%d", name, address.Host, *address.Port)
+	}
+	return fmt.Sprintf("%s|%s", name, address.Host)
+}
+
+func historySize() int {
+	if value, exists := os.LookupEnv(envHistorySize); exists {
+		if size, err := strconv.Atoi(value); err == nil && size > 0 {
+			if size < recentDurations {
+				log.WithFields(log.Fields{"env": envHistorySize, "value": value, "minimum": recentDurations}).
+					Warn("the history size is below the minimum, using the minimum")
+				return recentDurations
+			}
+			return size
+		}
+		log.WithFields(log.Fields{"env": envHistorySize, "value": value, "default": defaultHistorySize}).
+			Warn("could not parse the history size, using the default")
+	}
+	return defaultHistorySize
+}
+
+func port(p *int) int {
+	if p == nil {
+		return -1
+	}
+	return *p
+}
@@ -0,0 +1,77 @@
+package status
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bakito/dns-checker/pkg/check"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func Test_Store(t *testing.T) {
+	s := NewStore(defaultHistorySize)
+	port := 443
+	d := 2 * time.Millisecond
+
+	s.Update("probe-port", check.Address{Host: "b", Port: &port}, check.Result{Duration: &d, WorkerID: 3}, check.StateUp, false)
+	s.Update("dns", check.Address{Host: "b", Port: &port}, check.Result{Duration: &d}, check.StateUp, false)
+	s.Update("dns", check.Address{Host: "a"}, check.Result{Duration: &d}, check.StateUp, false)
+	s.Update("dns", check.Address{Host: "a"}, check.Result{Duration: &d, Err: errors.New("failed")}, check.StateDown, true)
+
+	entries := s.List()
+	assert.Assert(t, is.Len(entries, 3))
+	assert.Assert(t, is.Equal(entries[0].Target, "a"))
+	assert.Assert(t, !entries[0].OK)
+	assert.Assert(t, is.Equal(entries[0].Error, "failed"))
+	assert.Assert(t, is.Equal(entries[0].State, check.StateDown))
+	assert.Assert(t, entries[0].Flapping)
+	assert.Assert(t, entries[0].LastSuccess != nil)
+	assert.Assert(t, entries[0].LastFailure != nil)
+	assert.Assert(t, is.Equal(entries[1].Check, "dns"))
+	assert.Assert(t, is.Equal(entries[2].Check, "probe-port"))
+	assert.Assert(t, is.Equal(entries[2].Duration, 2.))
+	assert.Assert(t, is.DeepEqual(entries[0].Durations, []float64{2, 2}))
+	assert.Assert(t, is.Equal(entries[2].WorkerID, 3))
+	assert.Assert(t, entries[2].LastFailure == nil)
+
+	s.Delete("dns", check.Address{Host: "a"})
+	assert.Assert(t, is.Len(s.List(), 2))
+	assert.Assert(t, is.Len(s.history, 2))
+	assert.Assert(t, is.Len(s.windows, 2))
+}
+
+func Test_Store_ServeHTTP(t *testing.T) {
+	s := NewStore(defaultHistorySize)
+	d := time.Millisecond
+	s.Update("dns", check.Address{Host: "a"}, check.Result{Duration: &d}, check.StateUp, false)
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/status", nil))
+	assert.Assert(t, is.Equal(rec.Code, http.StatusOK))
+	assert.Assert(t, is.Equal(rec.Header().Get("Content-Type"), "application/json"))
+
+	var entries []Entry
+	assert.Assert(t, is.Nil(json.Unmarshal(rec.Body.Bytes(), &entries)))
+	assert.Assert(t, is.Len(entries, 1))
+	assert.Assert(t, is.Equal(entries[0].State, check.StateUp))
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/status", nil))
+	assert.Assert(t, is.Equal(rec.Code, http.StatusMethodNotAllowed))
+}
+
+func Test_Store_recentDurations(t *testing.T) {
+	s := NewStore(defaultHistorySize)
+	for i := range recentDurations + 5 {
+		d := time.Duration(i) * time.Millisecond
+		s.Update("dns", check.Address{Host: "a"}, check.Result{Duration: &d}, check.StateUp, false)
+	}
+	entries := s.List()
+	assert.Assert(t, is.Len(entries[0].Durations, recentDurations))
+	assert.Assert(t, is.Equal(entries[0].Durations[0], 5.))
+}
@@ -0,0 +1,85 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bakito/dns-checker/pkg/check"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	promBridge "go.opentelemetry.io/contrib/bridges/prometheus"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+const (
+	// envMetricsExporter a ',' separated list of metric exporters (prometheus, otlp)
+	envMetricsExporter = "METRICS_EXPORTER"
+
+	envOTLPMetricsProtocol = "OTEL_EXPORTER_OTLP_METRICS_PROTOCOL"
+
+	// ExporterPrometheus expose the metrics on the prometheus endpoint
+	ExporterPrometheus = "prometheus"
+	// ExporterOTLP push the metrics to an otlp endpoint
+	ExporterOTLP = "otlp"
+)
+
+// MetricsExporters the enabled metrics exporters
+type MetricsExporters struct {
+	Prometheus bool
+	OTLP       bool
+}
+
+// EnabledMetricsExporters read the enabled metrics exporters; prometheus is enabled by default
+func EnabledMetricsExporters() (MetricsExporters, error) {
+	value, exists := os.LookupEnv(envMetricsExporter)
+	if !exists {
+		return MetricsExporters{Prometheus: true}, nil
+	}
+	var exporters MetricsExporters
+	for e := range strings.SplitSeq(value, check.Separator) {
+		switch strings.TrimSpace(e) {
+		case ExporterPrometheus:
+			exporters.Prometheus = true
+		case ExporterOTLP:
+			exporters.OTLP = true
+		default:
+			return exporters, fmt.Errorf("env var %s contains unknown exporter %q", envMetricsExporter, e)
+		}
+	}
+	return exporters, nil
+}
+
+// StartMetrics periodically export the metrics of the gatherer via otlp.
+// The endpoint, headers and interval are configured with the standard OTEL_* env variables.
+// The returned function flushes the pending metrics and stops the export.
+func StartMetrics(ctx context.Context, gatherer prometheus.Gatherer) (func(context.Context) error, error) {
+	p, err := protocol(envOTLPMetricsProtocol)
+	if err != nil {
+		return nil, err
+	}
+	res, err := newResource(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var exporter sdkmetric.Exporter
+	if p == protocolGRPC {
+		exporter, err = otlpmetricgrpc.New(ctx)
+	} else {
+		exporter, err = otlpmetrichttp.New(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	reader := sdkmetric.NewPeriodicReader(exporter,
+		sdkmetric.WithProducer(promBridge.NewMetricProducer(promBridge.WithGatherer(gatherer))))
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithResource(res), sdkmetric.WithReader(reader))
+
+	log.WithField("protocol", p).Info("Starting otlp metrics export")
+	return provider.Shutdown, nil
+}
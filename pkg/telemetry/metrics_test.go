@@ -0,0 +1,144 @@
+package telemetry
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	colmetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func Test_EnabledMetricsExporters(t *testing.T) {
+	e, err := EnabledMetricsExporters()
+	assert.Assert(t, is.Nil(err))
+	assert.Assert(t, is.DeepEqual(e, MetricsExporters{Prometheus: true}))
+
+	t.Setenv(envMetricsExporter, "otlp")
+	e, err = EnabledMetricsExporters()
+	assert.Assert(t, is.Nil(err))
+	assert.Assert(t, is.DeepEqual(e, MetricsExporters{OTLP: true}))
+
+	t.Setenv(envMetricsExporter, "prometheus, otlp")
+	e, err = EnabledMetricsExporters()
+	assert.Assert(t, is.Nil(err))
+	assert.Assert(t, is.DeepEqual(e, MetricsExporters{Prometheus: true, OTLP: true}))
+
+	t.Setenv(envMetricsExporter, "statsd")
+	_, err = EnabledMetricsExporters()
+	assert.Assert(t, is.Error(err, `env var METRICS_EXPORTER contains unknown exporter "statsd"`))
+}
+
+func Test_StartMetrics_http(t *testing.T) {
+	collector := &metricsCollector{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Assert(t, is.Equal(r.URL.Path, "/v1/metrics"))
+		b, _ := io.ReadAll(r.Body)
+		req := &colmetricpb.ExportMetricsServiceRequest{}
+		assert.Assert(t, is.Nil(proto.Unmarshal(b, req)))
+		collector.add(req)
+		w.Header().Set("Content-Type", "application/x-protobuf")
+	}))
+	defer srv.Close()
+
+	t.Setenv(envOTLPProtocol, protocolHTTP)
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", srv.URL)
+	exportTestMetric(t)
+
+	collector.assertReceived(t)
+}
+
+func Test_StartMetrics_grpc(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Assert(t, is.Nil(err))
+	collector := &metricsCollector{}
+	srv := grpc.NewServer()
+	colmetricpb.RegisterMetricsServiceServer(srv, collector)
+	go func() { _ = srv.Serve(lis) }()
+	defer srv.Stop()
+
+	t.Setenv(envOTLPProtocol, protocolGRPC)
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://"+lis.Addr().String())
+	t.Setenv("OTEL_EXPORTER_OTLP_INSECURE", "true")
+	exportTestMetric(t)
+
+	collector.assertReceived(t)
+}
+
+func Test_protocol(t *testing.T) {
+	p, err := protocol(envOTLPMetricsProtocol)
+	assert.Assert(t, is.Nil(err))
+	assert.Assert(t, is.Equal(p, protocolHTTP))
+
+	t.Setenv(envOTLPProtocol, protocolGRPC)
+	p, err = protocol(envOTLPMetricsProtocol)
+	assert.Assert(t, is.Nil(err))
+	assert.Assert(t, is.Equal(p, protocolGRPC))
+
+	t.Setenv(envOTLPMetricsProtocol, "http/json")
+	_, err = protocol(envOTLPMetricsProtocol)
+	assert.Assert(t, is.ErrorContains(err, `otlp protocol "http/json" is not supported`))
+}
+
+func exportTestMetric(t *testing.T) {
+	t.Setenv("OTEL_SERVICE_NAME", "test-checker")
+	registry := prometheus.NewRegistry()
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "dns_checker_check_error"}, []string{"target"})
+	registry.MustRegister(gauge)
+	gauge.WithLabelValues("host.name").Set(1)
+
+	shutdown, err := StartMetrics(context.Background(), registry)
+	assert.Assert(t, is.Nil(err))
+	// shutdown flushes the pending metrics
+	assert.Assert(t, is.Nil(shutdown(context.Background())))
+}
+
+type metricsCollector struct {
+	colmetricpb.UnimplementedMetricsServiceServer
+	lock     sync.Mutex
+	requests []*colmetricpb.ExportMetricsServiceRequest
+}
+
+func (c *metricsCollector) Export(_ context.Context, req *colmetricpb.ExportMetricsServiceRequest) (*colmetricpb.ExportMetricsServiceResponse, error) {
+	c.add(req)
+	return &colmetricpb.ExportMetricsServiceResponse{}, nil
+}
+
+func (c *metricsCollector) add(req *colmetricpb.ExportMetricsServiceRequest) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.requests = append(c.requests, req)
+}
+
+func (c *metricsCollector) assertReceived(t *testing.T) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	assert.Assert(t, is.Len(c.requests, 1))
+
+	rm := c.requests[0].GetResourceMetrics()
+	assert.Assert(t, is.Len(rm, 1))
+	attributes := map[string]string{}
+	for _, a := range rm[0].GetResource().GetAttributes() {
+		attributes[a.GetKey()] = a.GetValue().GetStringValue()
+	}
+	assert.Assert(t, is.Equal(attributes["service.name"], "test-checker"))
+	assert.Assert(t, attributes["service.version"] != "")
+	assert.Assert(t, attributes["service.instance.id"] != "")
+
+	var names []string
+	for _, sm := range rm[0].GetScopeMetrics() {
+		for _, m := range sm.GetMetrics() {
+			names = append(names, m.GetName())
+			assert.Assert(t, is.Equal(m.GetGauge().GetDataPoints()[0].GetAsDouble(), 1.))
+		}
+	}
+	assert.Assert(t, is.DeepEqual(names, []string{"dns_checker_check_error"}))
+}
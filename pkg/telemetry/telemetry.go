@@ -0,0 +1,55 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bakito/dns-checker/version"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+)
+
+const (
+	envOTLPProtocol = "OTEL_EXPORTER_OTLP_PROTOCOL"
+
+	protocolGRPC = "grpc"
+	protocolHTTP = "http/protobuf"
+
+	serviceName = "dns-checker"
+)
+
+// protocol the otlp protocol of the signal; the signal specific env variable has precedence
+func protocol(signalEnv string) (string, error) {
+	p := protocolHTTP
+	if value, exists := os.LookupEnv(envOTLPProtocol); exists {
+		p = value
+	}
+	if value, exists := os.LookupEnv(signalEnv); exists {
+		p = value
+	}
+	p = strings.TrimSpace(p)
+	if p != protocolGRPC && p != protocolHTTP {
+		return "", fmt.Errorf("otlp protocol %q is not supported, use %q or %q", p, protocolGRPC, protocolHTTP)
+	}
+	return p, nil
+}
+
+// newResource the resource describing this checker. OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES have precedence.
+func newResource(ctx context.Context) (*resource.Resource, error) {
+	instance, err := os.Hostname()
+	if err != nil {
+		instance = serviceName
+	}
+	defaults := resource.NewSchemaless(
+		semconv.ServiceName(serviceName),
+		semconv.ServiceVersion(version.Version),
+		semconv.ServiceInstanceID(instance),
+	)
+	env, err := resource.New(ctx, resource.WithFromEnv())
+	if err != nil {
+		return nil, err
+	}
+	return resource.Merge(defaults, env)
+}
@@ -0,0 +1,69 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const (
+	// envTracesExporter the traces exporter (none, otlp)
+	envTracesExporter = "TRACES_EXPORTER"
+
+	envOTLPTracesProtocol = "OTEL_EXPORTER_OTLP_TRACES_PROTOCOL"
+
+	exporterNone = "none"
+)
+
+// TracingEnabled check if the traces are exported; tracing is disabled by default
+func TracingEnabled() (bool, error) {
+	value, exists := os.LookupEnv(envTracesExporter)
+	if !exists {
+		return false, nil
+	}
+	switch strings.TrimSpace(value) {
+	case ExporterOTLP:
+		return true, nil
+	case exporterNone, "":
+		return false, nil
+	default:
+		return false, fmt.Errorf("env var %s contains unknown exporter %q", envTracesExporter, value)
+	}
+}
+
+// StartTracing export the traces of the check executions via otlp and register the global tracer provider.
+// The endpoint, headers and sampler are configured with the standard OTEL_* env variables.
+// The returned function flushes the pending spans and stops the export.
+func StartTracing(ctx context.Context) (func(context.Context) error, error) {
+	p, err := protocol(envOTLPTracesProtocol)
+	if err != nil {
+		return nil, err
+	}
+	res, err := newResource(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var exporter sdktrace.SpanExporter
+	if p == protocolGRPC {
+		exporter, err = otlptracegrpc.New(ctx)
+	} else {
+		exporter, err = otlptracehttp.New(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(sdktrace.WithResource(res), sdktrace.WithBatcher(exporter))
+	otel.SetTracerProvider(provider)
+
+	log.WithField("protocol", p).Info("Starting otlp traces export")
+	return provider.Shutdown, nil
+}
@@ -0,0 +1,66 @@
+package telemetry
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/bakito/dns-checker/pkg/check"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/proto"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func Test_TracingEnabled(t *testing.T) {
+	enabled, err := TracingEnabled()
+	assert.Assert(t, is.Nil(err))
+	assert.Assert(t, !enabled)
+
+	t.Setenv(envTracesExporter, "otlp")
+	enabled, err = TracingEnabled()
+	assert.Assert(t, is.Nil(err))
+	assert.Assert(t, enabled)
+
+	t.Setenv(envTracesExporter, "jaeger")
+	_, err = TracingEnabled()
+	assert.Assert(t, is.Error(err, `env var TRACES_EXPORTER contains unknown exporter "jaeger"`))
+}
+
+func Test_StartTracing(t *testing.T) {
+	var lock sync.Mutex
+	var spans []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Assert(t, is.Equal(r.URL.Path, "/v1/traces"))
+		b, _ := io.ReadAll(r.Body)
+		req := &coltracepb.ExportTraceServiceRequest{}
+		assert.Assert(t, is.Nil(proto.Unmarshal(b, req)))
+		lock.Lock()
+		defer lock.Unlock()
+		for _, rs := range req.GetResourceSpans() {
+			for _, ss := range rs.GetScopeSpans() {
+				for _, s := range ss.GetSpans() {
+					spans = append(spans, s.GetName())
+				}
+			}
+		}
+		w.Header().Set("Content-Type", "application/x-protobuf")
+	}))
+	defer srv.Close()
+
+	t.Setenv(envOTLPProtocol, protocolHTTP)
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", srv.URL)
+	shutdown, err := StartTracing(context.Background())
+	assert.Assert(t, is.Nil(err))
+
+	_, span := check.StartSpan(context.Background(), "check dns")
+	span.End()
+	assert.Assert(t, is.Nil(shutdown(context.Background())))
+
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Assert(t, is.DeepEqual(spans, []string{"check dns"}))
+}
@@ -1,6 +1,29 @@
 package version
 
+import (
+	"runtime"
+	"runtime/debug"
+)
+
 var (
 	// Version the module version
 	Version = "v2"
+	// Revision the vcs revision; taken from the build info if not set at build time
+	Revision = ""
+	// GoVersion the go version used to build the binary
+	GoVersion = runtime.Version()
 )
+
+func init() {
+	if Revision != "" {
+		return
+	}
+	Revision = "unknown"
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range info.Settings {
+			if s.Key == "vcs.revision" {
+				Revision = s.Value
+			}
+		}
+	}
+}